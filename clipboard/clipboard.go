@@ -0,0 +1,87 @@
+// Package clipboard copies text to the system clipboard, falling back to the
+// OSC52 terminal escape sequence so copying also works over SSH and in tmux.
+package clipboard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// MethodOSC52 is reported by Copy when the text was sent to the terminal.
+const MethodOSC52 = "osc52"
+
+// copyCommand is a clipboard tool and the environment it needs to be useful.
+type copyCommand struct {
+	args []string
+	// env, when set, must be present in the environment for the tool to apply.
+	env string
+}
+
+// copyCommands are tried in order; the first one installed is used.
+var copyCommands = []copyCommand{
+	{args: []string{"wl-copy"}, env: "WAYLAND_DISPLAY"},
+	{args: []string{"xclip", "-selection", "clipboard"}, env: "DISPLAY"},
+	{args: []string{"xsel", "--clipboard", "--input"}, env: "DISPLAY"},
+	{args: []string{"pbcopy"}},
+	{args: []string{"clip.exe"}},
+}
+
+// Hooks replaced in tests.
+var (
+	lookPath = exec.LookPath
+	runCopy  = func(args []string, text string) error {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	openTerminal = func() (io.WriteCloser, error) {
+		return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	}
+)
+
+// Copy places text on the clipboard and reports how it did so: the name of
+// the clipboard tool used, or MethodOSC52. In an SSH session the local
+// clipboard tools are skipped, as they would copy to the remote machine.
+func Copy(text string) (string, error) {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		for _, c := range copyCommands {
+			if c.env != "" && os.Getenv(c.env) == "" {
+				continue
+			}
+			if _, err := lookPath(c.args[0]); err != nil {
+				continue
+			}
+			if err := runCopy(c.args, text); err == nil {
+				return c.args[0], nil
+			}
+		}
+	}
+	if err := copyOSC52(text); err != nil {
+		return "", fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+	return MethodOSC52, nil
+}
+
+// copyOSC52 writes the OSC52 sequence for text to the controlling terminal,
+// wrapped for tmux or screen when running inside one.
+func copyOSC52(text string) error {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	tty, err := openTerminal()
+	if err != nil {
+		return fmt.Errorf("no terminal to send OSC52 sequence to: %w", err)
+	}
+	defer tty.Close()
+	_, err = seq.WriteTo(tty)
+	return err
+}
@@ -0,0 +1,75 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestCopy(t *testing.T) {
+	t.Setenv("SSH_TTY", "")
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm")
+
+	origLookPath, origRunCopy, origOpenTerminal := lookPath, runCopy, openTerminal
+	t.Cleanup(func() { lookPath, runCopy, openTerminal = origLookPath, origRunCopy, origOpenTerminal })
+
+	var ran []string
+	var copied string
+	lookPath = func(file string) (string, error) {
+		if file == "xsel" {
+			return "/usr/bin/xsel", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	runCopy = func(args []string, text string) error {
+		ran = args
+		copied = text
+		return nil
+	}
+	var tty bytes.Buffer
+	openTerminal = func() (io.WriteCloser, error) { return nopCloser{&tty}, nil }
+
+	method, err := Copy("https://console.cloud.google.com")
+	if err != nil || method != "xsel" {
+		t.Fatalf("Copy() = %s, %v; want xsel", method, err)
+	}
+	if strings.Join(ran, " ") != "xsel --clipboard --input" || copied != "https://console.cloud.google.com" {
+		t.Errorf("Copy() ran %v with %q", ran, copied)
+	}
+
+	// Over SSH the local tools are skipped in favour of OSC52.
+	t.Setenv("SSH_TTY", "/dev/pts/1")
+	method, err = Copy("hello")
+	if err != nil || method != MethodOSC52 {
+		t.Fatalf("Copy() over SSH = %s, %v; want %s", method, err, MethodOSC52)
+	}
+	if want := "\x1b]52;c;aGVsbG8=\x07"; tty.String() != want {
+		t.Errorf("Copy() wrote %q to the terminal, want %q", tty.String(), want)
+	}
+
+	// Inside tmux the sequence is wrapped in a passthrough.
+	tty.Reset()
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if _, err := Copy("hello"); err != nil {
+		t.Fatalf("Copy() in tmux error = %v", err)
+	}
+	if !strings.HasPrefix(tty.String(), "\x1bPtmux;") {
+		t.Errorf("Copy() in tmux wrote %q, want a tmux passthrough", tty.String())
+	}
+
+	openTerminal = func() (io.WriteCloser, error) { return nil, errors.New("no tty") }
+	if _, err := Copy("hello"); err == nil {
+		t.Error("Copy() without a terminal expected error, got nil")
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/launch"
+)
+
+var (
+	bookmarkEnvironmentFlag string
+	bookmarkTagFlags        []string
+	bookmarkFileFlag        string
+)
+
+// bookmarkCmd groups the commands that manage bookmarked console URLs.
+var bookmarkCmd = &cobra.Command{
+	Use:   "bookmark",
+	Short: "Manage and open bookmarked console URLs.",
+}
+
+var bookmarkAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Add a bookmark to the configuration file.",
+	Long: `Add saves a console URL under a name in the configuration file that
+takes precedence (or the file given with --file), keeping the rest of the
+file and its comments. The URL may contain template actions such as
+{{.ProjectID}}, rendered against the --environment when it is opened.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, bookmarkURL := args[0], args[1]
+		if bookmarkEnvironmentFlag != "" {
+			if _, ok := loadedConfig.Environments[bookmarkEnvironmentFlag]; !ok {
+				return fmt.Errorf("environment '%s' is not defined in the top-level environments", bookmarkEnvironmentFlag)
+			}
+		}
+		path := bookmarkFileFlag
+		if path == "" {
+			path = loadedConfig.Path()
+		}
+		if err := editableFile(path); err != nil {
+			return err
+		}
+		bookmark := config.Bookmark{URL: bookmarkURL, Environment: bookmarkEnvironmentFlag, Tags: bookmarkTagFlags}
+		if err := config.AddBookmark(path, name, bookmark); err != nil {
+			return err
+		}
+		fmt.Printf("Added bookmark '%s' to %s\n", name, path)
+		return nil
+	},
+}
+
+var bookmarkListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the bookmarks.",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range config.SortedKeys(loadedConfig.Bookmarks) {
+			bookmark := loadedConfig.Bookmarks[name]
+			details := []string{}
+			if bookmark.Environment != "" {
+				details = append(details, "environment: "+bookmark.Environment)
+			}
+			if len(bookmark.Tags) > 0 {
+				details = append(details, "tags: "+strings.Join(bookmark.Tags, ", "))
+			}
+			fmt.Printf("%-30s %s", name, bookmark.URL)
+			if len(details) > 0 {
+				fmt.Printf("  (%s)", strings.Join(details, "; "))
+			}
+			fmt.Println()
+		}
+	},
+}
+
+var bookmarkRemoveCmd = &cobra.Command{
+	Use:               "rm <name>",
+	Aliases:           []string{"remove"},
+	Short:             "Remove a bookmark from the configuration file that defines it.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: bookmarkCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		path := loadedConfig.BookmarkSource(name)
+		if path == "" {
+			_, err := loadedConfig.ResolveBookmark(name)
+			if err == nil {
+				err = fmt.Errorf("bookmark '%s' must be given by its full name to remove it", name)
+			}
+			return err
+		}
+		if err := editableFile(path); err != nil {
+			return err
+		}
+		if err := config.RemoveBookmark(path, name); err != nil {
+			return err
+		}
+		fmt.Printf("Removed bookmark '%s' from %s\n", name, path)
+		return nil
+	},
+}
+
+var bookmarkOpenCmd = &cobra.Command{
+	Use:               "open <name>",
+	Short:             "Open a bookmark.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: bookmarkCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFlags(); err != nil {
+			return err
+		}
+		launcher := launch.New(loadedConfig)
+		target, err := launcher.ResolveBookmark(args[0], launchOptions())
+		if err != nil {
+			return err
+		}
+		return deliverTarget(launcher, target)
+	},
+}
+
+// editableFile refuses to edit files that are only a cached copy of a
+// remote include.
+func editableFile(path string) error {
+	for _, f := range loadedConfig.Files() {
+		if f.Remote != "" && (f.Remote == path || f.Path == path) {
+			return fmt.Errorf("'%s' is a remote include and cannot be edited here", f.Remote)
+		}
+	}
+	return nil
+}
+
+// bookmarkCompletion suggests bookmark names.
+func bookmarkCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if loadedConfig == nil || len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return config.SortedKeys(loadedConfig.Bookmarks), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	bookmarkAddCmd.Flags().StringVarP(&bookmarkEnvironmentFlag, "environment", "e", "", "Top-level environment whose project, account and browser the bookmark uses")
+	bookmarkAddCmd.Flags().StringArrayVar(&bookmarkTagFlags, "tag", nil, "Tag for the bookmark; repeatable")
+	bookmarkAddCmd.Flags().StringVar(&bookmarkFileFlag, "file", "", "Configuration file to add the bookmark to (default: the file that takes precedence)")
+	addOpenFlags(bookmarkOpenCmd)
+	bookmarkCmd.AddCommand(bookmarkAddCmd, bookmarkListCmd, bookmarkRemoveCmd, bookmarkOpenCmd)
+	rootCmd.AddCommand(bookmarkCmd)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/url"
+)
+
+// catalogCmd lists the service types gcp-launch can open without a url_template.
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "List the built-in service types and their required configuration.",
+	Args:  cobra.NoArgs,
+	// The catalog is built in and needs no configuration.
+	Annotations: map[string]string{withoutConfig: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, e := range url.Catalog() {
+			fmt.Printf("%-26s %-40s requires: %s\n", e.Name, e.Description, strings.Join(e.Required, ", "))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(catalogCmd)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/url"
+)
+
+// configCmd groups the commands that inspect the loaded configuration.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the gcp-launch configuration.",
+}
+
+// configSourcesCmd reports which files were loaded and which file each
+// environment came from.
+var configSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Show the loaded configuration files and which file defined each environment.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Configuration files (highest precedence first):")
+		for _, f := range loadedConfig.Files() {
+			if f.Remote != "" {
+				fmt.Printf("  %s (%s, cached at %s)\n", f.Remote, f.Source, f.Path)
+			} else {
+				fmt.Printf("  %s (%s)\n", f.Path, f.Source)
+			}
+		}
+		fmt.Println()
+		fmt.Println("Environments:")
+		for _, service := range config.SortedKeys(loadedConfig.Services) {
+			for _, environment := range config.SortedKeys(loadedConfig.Services[service].Environments) {
+				fmt.Printf("  %-40s %s\n", service+"/"+environment, loadedConfig.EnvironmentSource(service, environment))
+			}
+		}
+	},
+}
+
+// configValidateCmd checks configuration files and exits non-zero on any
+// problem, for use in CI and pre-commit hooks.
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "Check the configuration for unknown keys, missing required keys and malformed values.",
+	Long: `Validate checks the loaded configuration, or the given files, for
+unknown keys, keys required by the service type (e.g. region for cloudrun),
+url_templates that do not parse, and malformed project IDs, regions,
+locations and accounts. Each problem is reported as file:line:column and
+the command exits non-zero if any are found.`,
+	SilenceUsage: true,
+	// The given files are validated instead of the loaded configuration, so
+	// it is loaded here rather than before the command runs.
+	Annotations: map[string]string{withoutConfig: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		problems := 0
+		validate := func(cfg *config.Config) {
+			for _, e := range cfg.Validate(catalogRules) {
+				fmt.Fprintln(os.Stderr, e)
+				problems++
+			}
+		}
+		if len(args) == 0 {
+			if cfg, err := loadConfig(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				problems++
+			} else {
+				validate(cfg)
+			}
+		}
+		for _, path := range args {
+			cfg, err := config.LoadConfig(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				problems++
+				continue
+			}
+			validate(cfg)
+		}
+		if problems > 0 {
+			return fmt.Errorf("%d problem(s) found in configuration", problems)
+		}
+		fmt.Println("Configuration is valid.")
+		return nil
+	},
+}
+
+// configSchemaCmd prints the JSON Schema of the configuration file.
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the configuration file, for editor completion.",
+	Args:  cobra.NoArgs,
+	// The schema is built in and needs no configuration.
+	Annotations: map[string]string{withoutConfig: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Stdout.Write(config.Schema)
+	},
+}
+
+// catalogRules reports the keys a built-in service type requires.
+func catalogRules(serviceType string) ([]string, bool) {
+	entry, ok := url.LookupCatalog(serviceType)
+	if !ok {
+		return nil, false
+	}
+	return entry.Required, true
+}
+
+func init() {
+	configCmd.AddCommand(configSourcesCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
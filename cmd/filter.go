@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/config"
+)
+
+// Flags selecting environments by their tags and labels.
+var (
+	tagFlags   []string
+	labelFlags []string
+	teamFlag   string
+	tierFlag   string
+)
+
+// filterFlags are the names of the flags that select environments.
+var filterFlags = []string{"tag", "label", "team", "tier"}
+
+// addFilterFlags adds the flags that select environments by tag and label.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&tagFlags, "tag", nil, "Only environments with this tag; repeatable")
+	cmd.Flags().StringArrayVar(&labelFlags, "label", nil, "Only environments with this label as key=value; repeatable")
+	cmd.Flags().StringVar(&teamFlag, "team", "", "Only environments of this team (same as --label team=<team>)")
+	cmd.Flags().StringVar(&tierFlag, "tier", "", "Only environments of this tier (same as --label tier=<tier>)")
+	_ = cmd.RegisterFlagCompletionFunc("tag", configValues(func(c *config.Config) []string { return c.TagValues() }))
+	_ = cmd.RegisterFlagCompletionFunc("label", configValues(func(c *config.Config) []string { return c.LabelValues("") }))
+	_ = cmd.RegisterFlagCompletionFunc("team", configValues(func(c *config.Config) []string { return c.LabelValues("team") }))
+	_ = cmd.RegisterFlagCompletionFunc("tier", configValues(func(c *config.Config) []string { return c.LabelValues("tier") }))
+}
+
+// filterFlagsChanged reports whether any environment filter was given.
+func filterFlagsChanged(cmd *cobra.Command) bool {
+	for _, name := range filterFlags {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// environmentFilter builds the environment filter from the flags.
+func environmentFilter() (config.EnvironmentFilter, error) {
+	filter := config.EnvironmentFilter{Tags: tagFlags}
+	labels := map[string]string{}
+	for _, l := range labelFlags {
+		key, value, err := config.ParseLabel(l)
+		if err != nil {
+			return config.EnvironmentFilter{}, err
+		}
+		labels[key] = value
+	}
+	if teamFlag != "" {
+		labels["team"] = teamFlag
+	}
+	if tierFlag != "" {
+		labels["tier"] = tierFlag
+	}
+	if len(labels) > 0 {
+		filter.Labels = labels
+	}
+	return filter, nil
+}
+
+// configValues completes a flag with values taken from the configuration.
+func configValues(values func(*config.Config) []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if loadedConfig == nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return values(loadedConfig), cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/history"
+	"github.com/tom-gray/gcp-launch/launch"
+)
+
+var historyLimitFlag int
+
+// historyCmd lists recorded launches, most recent first.
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recent launches, most recent first.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries := loadHistory()
+		for i := len(entries) - 1; i >= 0 && i >= len(entries)-historyLimitFlag; i-- {
+			e := entries[i]
+			fmt.Printf("%s  %-40s %s\n", e.Time.Local().Format("2006-01-02 15:04"), history.TargetKey(e), e.URL)
+		}
+	},
+}
+
+// lastCmd relaunches the most recent launch, like `gcp-launch -`.
+var lastCmd = &cobra.Command{
+	Use:   "last",
+	Short: "Launch the most recently launched target again (same as 'gcp-launch -').",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return relaunchLast(cmd)
+	},
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimitFlag, "limit", "n", 20, "Number of launches to list")
+	addOpenFlags(lastCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(lastCmd)
+}
+
+// relaunchLast launches the target of the most recent launch again. The
+// recorded URL is reused, as it includes the context argument, tab and log
+// query of that launch, unless --account asks for a different account.
+func relaunchLast(cmd *cobra.Command) error {
+	if err := validateOutputFlags(); err != nil {
+		return err
+	}
+	entries := loadHistory()
+	if len(entries) == 0 {
+		return fmt.Errorf("no launches recorded yet")
+	}
+	last := entries[len(entries)-1]
+	debugLog("Relaunching %s from %s", history.TargetKey(last), last.Time)
+	launcher := launch.New(loadedConfig)
+	var target launch.Target
+	var err error
+	if last.Bookmark != "" {
+		target, err = launcher.ResolveBookmark(last.Bookmark, launchOptions())
+	} else {
+		target, err = launcher.Resolve(last.Service, last.Environment, launchOptions())
+	}
+	if err != nil {
+		return fmt.Errorf("cannot relaunch %s: %w", history.TargetKey(last), err)
+	}
+	if last.URL != "" && !cmd.Flags().Changed("account") {
+		target.URL = last.URL
+	}
+	return deliverTarget(launcher, target)
+}
+
+// recordLaunch adds a launched target to the history. The history is a
+// convenience, so failing to record is only reported in debug mode.
+func recordLaunch(target launch.Target) {
+	store, err := history.Open()
+	if err == nil {
+		err = store.Append(history.Entry{Service: target.Service, Environment: target.Environment, Bookmark: target.Bookmark, URL: target.URL, Time: time.Now()})
+	}
+	if err != nil {
+		debugLog("Could not record launch in history: %v", err)
+	}
+}
+
+// loadHistory returns the recorded launches, or none if the history cannot
+// be read.
+func loadHistory() []history.Entry {
+	store, err := history.Open()
+	if err != nil {
+		debugLog("Could not read history: %v", err)
+		return nil
+	}
+	entries, err := store.Load()
+	if err != nil {
+		debugLog("Could not read history: %v", err)
+	}
+	return entries
+}
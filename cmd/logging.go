@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/config"
+)
+
+// Flags assembling a Logs Explorer query; only meaningful for logging.
+var (
+	queryNameFlag    string
+	logResourceFlag  string
+	logSeverityFlag  string
+	logLabelFlags    []string
+	logTextFlag      string
+	logSinceFlag     string
+	logFromFlag      string
+	logToFlag        string
+	logQueryFlagList = []string{"query", "resource-type", "severity", "log-label", "text", "since", "from", "to"}
+)
+
+func init() {
+	rootCmd.Flags().StringVar(&queryNameFlag, "query", "", "Named log query from the environment's queries (logging only)")
+	rootCmd.Flags().StringVar(&logResourceFlag, "resource-type", "", "Restrict logs to a monitored resource type, e.g. cloud_run_revision (logging only)")
+	rootCmd.Flags().StringVar(&logSeverityFlag, "severity", "", "Minimum log severity, e.g. WARNING (logging only)")
+	rootCmd.Flags().StringArrayVar(&logLabelFlags, "log-label", nil, "Restrict logs to a label as key=value; repeatable (logging only)")
+	rootCmd.Flags().StringVar(&logTextFlag, "text", "", "Free-text search across all log fields (logging only)")
+	rootCmd.Flags().StringVar(&logSinceFlag, "since", "", "Relative time window, e.g. 30m, 1h, 7d (logging only)")
+	rootCmd.Flags().StringVar(&logFromFlag, "from", "", "Start of an absolute time window in RFC 3339 (logging only)")
+	rootCmd.Flags().StringVar(&logToFlag, "to", "", "End of an absolute time window in RFC 3339 (logging only)")
+	_ = rootCmd.RegisterFlagCompletionFunc("query", queryNameCompletion)
+}
+
+// logQueryFlagsChanged reports whether any log query flag was given.
+func logQueryFlagsChanged(cmd *cobra.Command) bool {
+	for _, name := range logQueryFlagList {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// logQueryFromFlags builds the query overrides given by the individual
+// query flags. The named query selected with --query is applied by the
+// launcher underneath them.
+func logQueryFromFlags() (config.LogQuery, error) {
+	labels := map[string]string{}
+	for _, l := range logLabelFlags {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return config.LogQuery{}, fmt.Errorf("invalid --log-label '%s' (expected key=value)", l)
+		}
+		labels[k] = v
+	}
+	return config.LogQuery{
+		ResourceType: logResourceFlag,
+		Severity:     logSeverityFlag,
+		Labels:       labels,
+		Text:         logTextFlag,
+		Since:        logSinceFlag,
+		From:         logFromFlag,
+		To:           logToFlag,
+	}, nil
+}
+
+// queryNameCompletion suggests the named queries of the environment given
+// as the second argument.
+func queryNameCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if loadedConfig == nil || len(args) < 2 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	service, err := loadedConfig.ResolveService(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	environment, err := loadedConfig.ResolveEnvironment(service, args[1])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	envConfig, ok := loadedConfig.Services[service].Environments[environment]
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return config.SortedKeys(envConfig.Queries), cobra.ShellCompDirectiveNoFileComp
+}
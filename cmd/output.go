@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/clipboard"
+	"github.com/tom-gray/gcp-launch/launch"
+)
+
+const (
+	outputPlain = "plain"
+	outputJSON  = "json"
+)
+
+// Flags selecting whether and how URLs are written instead of opened.
+var (
+	printFlag  bool
+	dryRunFlag bool
+	copyFlag   bool
+	outputFlag string
+)
+
+// targetOutput is the JSON representation of a resolved target.
+type targetOutput struct {
+	Service     string `json:"service,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Bookmark    string `json:"bookmark,omitempty"`
+	ProjectID   string `json:"project_id,omitempty"`
+	URL         string `json:"url"`
+	Account     string `json:"account,omitempty"`
+	Browser     string `json:"browser,omitempty"`
+	Protected   bool   `json:"protected,omitempty"`
+}
+
+// addOpenFlags adds the flags that choose how a target is opened, or
+// printed or copied instead, to a subcommand that opens a single target.
+func addOpenFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&browserFlag, "browser", "", "How to open the URL: default, $BROWSER or a command template")
+	cmd.Flags().StringVar(&accountFlag, "account", "", "Google account (email or authuser index) to open the URL as")
+	cmd.Flags().BoolVar(&printFlag, "print", false, "Print the URL instead of opening it")
+	cmd.Flags().BoolVar(&copyFlag, "copy", false, "Copy the URL to the clipboard instead of opening it")
+	cmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Show what would be opened, and how, without opening it")
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", outputPlain, "Output format for --print and --dry-run: plain or json")
+	cmd.MarkFlagsMutuallyExclusive("copy", "dry-run")
+	addProtectFlags(cmd)
+}
+
+// copyTargets places the URLs of several targets on the clipboard, one per
+// line, and reports them on stderr.
+func copyTargets(targets []launch.Target) error {
+	if len(targets) == 1 {
+		return copyTarget(targets[0])
+	}
+	urls := make([]string, len(targets))
+	for i, t := range targets {
+		urls[i] = t.URL
+	}
+	method, err := clipboard.Copy(strings.Join(urls, "\n"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Copied %d URLs to clipboard (via %s)\n", len(urls), method)
+	return nil
+}
+
+// copyTarget places the target's URL on the clipboard and reports it on
+// stderr, keeping stdout for --print output.
+func copyTarget(target launch.Target) error {
+	method, err := clipboard.Copy(target.URL)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Copied to clipboard (via %s): %s\n", method, target.URL)
+	return nil
+}
+
+// validateOutputFlags checks the --output value.
+func validateOutputFlags() error {
+	if outputFlag != outputPlain && outputFlag != outputJSON {
+		return fmt.Errorf("invalid --output '%s' (expected %s or %s)", outputFlag, outputPlain, outputJSON)
+	}
+	return nil
+}
+
+// writeTargets writes resolved targets in the selected output format: one URL
+// per line for --print, a description of the launch for --dry-run, or a JSON
+// object per line for --output json.
+func writeTargets(w io.Writer, targets []launch.Target) error {
+	for _, t := range targets {
+		var err error
+		switch {
+		case outputFlag == outputJSON:
+			var data []byte
+			data, err = json.Marshal(targetOutput{
+				Service:     t.Service,
+				Environment: t.Environment,
+				Bookmark:    t.Bookmark,
+				ProjectID:   t.Config.ProjectID,
+				URL:         t.URL,
+				Account:     t.Config.Account,
+				Browser:     t.Browser,
+				Protected:   t.Protected,
+			})
+			if err == nil {
+				_, err = fmt.Fprintln(w, string(data))
+			}
+		case dryRunFlag:
+			browser := t.Browser
+			if browser == "" {
+				browser = "default"
+			}
+			confirmation := ""
+			if t.Protected {
+				confirmation = ", after confirmation"
+			}
+			if t.Bookmark != "" {
+				_, err = fmt.Fprintf(w, "Would open %s for bookmark %s with browser %s%s\n", t.URL, t.Bookmark, browser, confirmation)
+			} else {
+				_, err = fmt.Fprintf(w, "Would open %s for %s in %s (project %s) with browser %s%s\n", t.URL, t.Service, t.Environment, t.Config.ProjectID, browser, confirmation)
+			}
+		default:
+			_, err = fmt.Fprintln(w, t.URL)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
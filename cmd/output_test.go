@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/launch"
+	"github.com/tom-gray/gcp-launch/tui"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got with testdata/<name>.golden, rewriting the file
+// instead with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// setOutputFlags sets the output flags for the duration of the test.
+func setOutputFlags(t *testing.T, print, dryRun bool, output string) {
+	t.Helper()
+	oldPrint, oldDryRun, oldOutput, oldDelay := printFlag, dryRunFlag, outputFlag, delayFlag
+	t.Cleanup(func() {
+		printFlag, dryRunFlag, outputFlag, delayFlag = oldPrint, oldDryRun, oldOutput, oldDelay
+	})
+	printFlag, dryRunFlag, outputFlag, delayFlag = print, dryRun, output, 0
+}
+
+// captureStdout returns what run writes to stdout.
+func captureStdout(t *testing.T, run func() error) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+	runErr := run()
+	w.Close()
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	return <-output
+}
+
+var outputCases = []struct {
+	name   string
+	print  bool
+	dryRun bool
+	output string
+}{
+	{name: "plain", print: true, output: outputPlain},
+	{name: "dry_run", dryRun: true, output: outputPlain},
+	{name: "json", print: true, output: outputJSON},
+	{name: "dry_run_json", dryRun: true, output: outputJSON},
+}
+
+func TestWriteTargets(t *testing.T) {
+	targets := []launch.Target{
+		{
+			Service:     "cloudrun",
+			Environment: "prod",
+			Config:      config.EnvironmentConfig{ProjectID: "acme-prod", Region: "europe-west1", Account: "ops@example.com"},
+			URL:         "https://console.cloud.google.com/run?project=acme-prod&authuser=ops%40example.com",
+			Browser:     "firefox -P work {{.URL}}",
+			Protected:   true,
+		},
+		{
+			Service:     "logging",
+			Environment: "dev",
+			Config:      config.EnvironmentConfig{ProjectID: "acme-dev"},
+			URL:         "https://console.cloud.google.com/logs/query?project=acme-dev",
+		},
+		{
+			Bookmark: "billing",
+			URL:      "https://console.cloud.google.com/billing",
+		},
+	}
+	for _, tt := range outputCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setOutputFlags(t, tt.print, tt.dryRun, tt.output)
+			var buf bytes.Buffer
+			if err := writeTargets(&buf, targets); err != nil {
+				t.Fatalf("writeTargets failed: %v", err)
+			}
+			checkGolden(t, "write_targets_"+tt.name, buf.Bytes())
+		})
+	}
+}
+
+func TestReportTUI(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := &config.Config{
+		// "true" stands in for the browser when a target is opened
+		Browser: "true",
+		Services: map[string]config.ServiceTypeConfig{
+			"bigquery": {Environments: map[string]config.EnvironmentConfig{
+				"dev":     {ProjectID: "acme-dev"},
+				"staging": {ProjectID: "acme-staging"},
+			}},
+		},
+	}
+	defer func(old *config.Config) { loadedConfig = old }(loadedConfig)
+	loadedConfig = cfg
+
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	selections := map[string][]tea.Msg{
+		// Tab to the service list, pick bigquery and open its first environment
+		"single": {tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter}},
+		// Select both environments and open them together
+		"several": {tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter}, space, tea.KeyMsg{Type: tea.KeyDown}, space, tea.KeyMsg{Type: tea.KeyEnter}},
+	}
+	cases := append(outputCases, struct {
+		name   string
+		print  bool
+		dryRun bool
+		output string
+	}{name: "open", output: outputPlain})
+	for selection, keys := range selections {
+		for _, tt := range cases {
+			t.Run(selection+"_"+tt.name, func(t *testing.T) {
+				setOutputFlags(t, tt.print, tt.dryRun, tt.output)
+				m := tui.NewModel(cfg)
+				if tt.print || tt.dryRun {
+					m = m.WithoutOpening()
+				}
+				for _, key := range keys {
+					next, _ := m.Update(key)
+					m = next.(tui.Model)
+				}
+				got := captureStdout(t, func() error { return reportTUI(m) })
+				checkGolden(t, "report_tui_"+selection+"_"+tt.name, got)
+			})
+		}
+	}
+}
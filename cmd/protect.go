@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/launch"
+)
+
+// Flags for opening protected environments.
+var (
+	yesFlag      bool
+	readOnlyFlag bool
+)
+
+// stdin is where confirmations are read from, and interactive reports
+// whether it is a terminal to ask on. Tests replace both.
+var (
+	stdin       io.Reader = os.Stdin
+	interactive           = func() bool {
+		return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+	}
+)
+
+// addProtectFlags adds the flags that open protected environments without
+// asking for confirmation.
+func addProtectFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "Open protected environments without asking for confirmation")
+	cmd.Flags().BoolVar(&readOnlyFlag, "read-only", false, "Open protected environments as their read-only authuser, without confirmation")
+}
+
+// protectTargets switches protected targets to their read-only authuser
+// for --read-only and, unless open is false or --yes was given, asks for
+// every remaining protected environment to be confirmed by typing its
+// name. Without a terminal to ask on, protected environments are refused.
+func protectTargets(launcher *launch.Launcher, targets []launch.Target, open bool) ([]launch.Target, error) {
+	for i, target := range targets {
+		if target.Protected && readOnlyFlag && target.Config.Authuser != "" {
+			readOnly, err := launcher.ReadOnly(target)
+			if err != nil {
+				return nil, err
+			}
+			readOnly.Protected = false
+			targets[i] = readOnly
+		}
+	}
+	if !open || yesFlag {
+		return targets, nil
+	}
+	reader := bufio.NewReader(stdin)
+	confirmed := map[string]bool{}
+	readOnly := map[string]bool{}
+	for i, target := range targets {
+		if !target.Protected {
+			continue
+		}
+		env := target.Environment
+		if !confirmed[env] && !readOnly[env] {
+			choice, err := confirmEnvironment(reader, target)
+			if err != nil {
+				return nil, err
+			}
+			confirmed[env] = choice == env
+			readOnly[env] = choice == ""
+		}
+		if readOnly[env] {
+			switched, err := launcher.ReadOnly(target)
+			if err != nil {
+				return nil, err
+			}
+			targets[i] = switched
+		}
+	}
+	return targets, nil
+}
+
+// confirmEnvironment asks on stderr for a protected target's environment
+// name to be typed, or nothing to open it read-only when it has an
+// authuser, and returns the answer.
+func confirmEnvironment(reader *bufio.Reader, target launch.Target) (string, error) {
+	env := target.Environment
+	authuser := target.Config.Authuser
+	if !interactive() {
+		if authuser != "" {
+			return "", fmt.Errorf("environment '%s' is protected: pass --yes to open it, or --read-only to open it as %s", env, authuser)
+		}
+		return "", fmt.Errorf("environment '%s' is protected: pass --yes to open it", env)
+	}
+	fmt.Fprintf(os.Stderr, "\033[1;41m PROTECTED \033[0m Environment '%s' is protected.\n", env)
+	if authuser != "" {
+		fmt.Fprintf(os.Stderr, "Type '%s' to open it, or press Enter to open it read-only as %s: ", env, authuser)
+	} else {
+		fmt.Fprintf(os.Stderr, "Type '%s' to open it: ", env)
+	}
+	answer, err := reader.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", fmt.Errorf("no confirmation for protected environment '%s'", env)
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == env || (answer == "" && authuser != "") {
+		return answer, nil
+	}
+	return "", fmt.Errorf("confirmation did not match protected environment '%s': nothing was opened", env)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/launch"
+)
+
+func protectConfig() *config.Config {
+	environments := func() map[string]config.EnvironmentConfig {
+		return map[string]config.EnvironmentConfig{
+			"prod":    {ProjectID: "prod-project", Protected: true, Authuser: "ro@example.com"},
+			"staging": {ProjectID: "staging-project", Protected: true},
+			"dev":     {ProjectID: "dev-project"},
+		}
+	}
+	return &config.Config{
+		Services: map[string]config.ServiceTypeConfig{
+			"bigquery": {Environments: environments()},
+			"logging":  {Environments: environments()},
+		},
+	}
+}
+
+// setPrompt replaces the confirmation input and terminal check, and the
+// protection flags, for the duration of the test.
+func setPrompt(t *testing.T, input string, tty, yes, readOnly bool) {
+	t.Helper()
+	oldStdin, oldInteractive, oldYes, oldReadOnly := stdin, interactive, yesFlag, readOnlyFlag
+	t.Cleanup(func() {
+		stdin, interactive, yesFlag, readOnlyFlag = oldStdin, oldInteractive, oldYes, oldReadOnly
+	})
+	stdin = strings.NewReader(input)
+	interactive = func() bool { return tty }
+	yesFlag, readOnlyFlag = yes, readOnly
+}
+
+func TestConfirmEnvironment(t *testing.T) {
+	launcher := launch.New(protectConfig())
+	tests := []struct {
+		name        string
+		environment string
+		input       string
+		tty         bool
+		expected    string
+		expectError string
+	}{
+		{name: "typed name matches", environment: "staging", input: "staging\n", tty: true, expected: "staging"},
+		{name: "typed name with spaces", environment: "prod", input: "  prod  \n", tty: true, expected: "prod"},
+		{name: "typed name does not match", environment: "staging", input: "stagin\n", tty: true, expectError: "confirmation did not match protected environment 'staging'"},
+		{name: "enter with authuser", environment: "prod", input: "\n", tty: true, expected: ""},
+		{name: "enter without authuser", environment: "staging", input: "\n", tty: true, expectError: "did not match"},
+		{name: "no answer", environment: "staging", input: "", tty: true, expectError: "no confirmation for protected environment 'staging'"},
+		{name: "not a terminal", environment: "staging", input: "staging\n", expectError: "pass --yes to open it"},
+		{name: "not a terminal with authuser", environment: "prod", input: "prod\n", expectError: "or --read-only to open it as ro@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setPrompt(t, tt.input, tt.tty, false, false)
+			target, err := launcher.Resolve("bigquery", tt.environment, launch.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			answer, err := confirmEnvironment(bufio.NewReader(stdin), target)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("confirmEnvironment() error = %v, want it to contain %q", err, tt.expectError)
+				}
+				return
+			}
+			if err != nil || answer != tt.expected {
+				t.Errorf("confirmEnvironment() = %q, %v; want %q", answer, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProtectTargets(t *testing.T) {
+	const (
+		bigqueryProd     = "https://console.cloud.google.com/bigquery?project=prod-project"
+		bigqueryProdRO   = bigqueryProd + "&authuser=ro%40example.com"
+		bigqueryStaging  = "https://console.cloud.google.com/bigquery?project=staging-project"
+		bigqueryDev      = "https://console.cloud.google.com/bigquery?project=dev-project"
+		loggingProd      = "https://console.cloud.google.com/logs/query?project=prod-project"
+		loggingProdRO    = loggingProd + "&authuser=ro%40example.com"
+		loggingStaging   = "https://console.cloud.google.com/logs/query?project=staging-project"
+		nothingConfirmed = "did not match"
+	)
+	tests := []struct {
+		name        string
+		refs        []string
+		input       string
+		tty         bool
+		yes         bool
+		readOnly    bool
+		print       bool
+		expected    []string
+		expectError string
+	}{
+		{name: "unprotected", refs: []string{"bigquery/dev"}, expected: []string{bigqueryDev}},
+		{name: "confirmed", refs: []string{"bigquery/prod"}, input: "prod\n", tty: true, expected: []string{bigqueryProd}},
+		{name: "not confirmed", refs: []string{"bigquery/prod"}, input: "dev\n", tty: true, expectError: nothingConfirmed},
+		{name: "enter opens read-only", refs: []string{"bigquery/prod"}, input: "\n", tty: true, expected: []string{bigqueryProdRO}},
+		{name: "not a terminal", refs: []string{"bigquery/dev", "bigquery/staging"}, input: "staging\n", expectError: "environment 'staging' is protected"},
+		{name: "yes", refs: []string{"bigquery/prod", "bigquery/staging"}, yes: true, expected: []string{bigqueryProd, bigqueryStaging}},
+		{name: "read-only", refs: []string{"bigquery/prod"}, readOnly: true, expected: []string{bigqueryProdRO}},
+		{name: "read-only asks without authuser", refs: []string{"bigquery/prod", "bigquery/staging"}, readOnly: true, input: "staging\n", tty: true, expected: []string{bigqueryProdRO, bigqueryStaging}},
+		{name: "read-only and yes", refs: []string{"bigquery/prod", "bigquery/staging"}, readOnly: true, yes: true, expected: []string{bigqueryProdRO, bigqueryStaging}},
+		{name: "printed without asking", refs: []string{"bigquery/prod"}, print: true, expected: []string{bigqueryProd}},
+		{
+			name:     "one prompt per environment",
+			refs:     []string{"bigquery/prod", "logging/prod", "bigquery/staging", "logging/staging", "bigquery/dev"},
+			input:    "prod\nstaging\n",
+			tty:      true,
+			expected: []string{bigqueryProd, loggingProd, bigqueryStaging, loggingStaging, bigqueryDev},
+		},
+		{
+			name:     "read-only answer applies to the environment",
+			refs:     []string{"bigquery/prod", "logging/prod"},
+			input:    "\n",
+			tty:      true,
+			expected: []string{bigqueryProdRO, loggingProdRO},
+		},
+		{name: "second environment not confirmed", refs: []string{"bigquery/prod", "bigquery/staging"}, input: "prod\nprod\n", tty: true, expectError: nothingConfirmed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setPrompt(t, tt.input, tt.tty, tt.yes, tt.readOnly)
+			launcher := launch.New(protectConfig())
+			var targets []launch.Target
+			for _, ref := range tt.refs {
+				service, environment, _ := strings.Cut(ref, "/")
+				target, err := launcher.Resolve(service, environment, launch.Options{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				targets = append(targets, target)
+			}
+			protected, err := protectTargets(launcher, targets, !tt.print)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("protectTargets() error = %v, want it to contain %q", err, tt.expectError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("protectTargets() failed: %v", err)
+			}
+			var urls []string
+			for _, target := range protected {
+				urls = append(urls, target.URL)
+			}
+			if !reflect.DeepEqual(urls, tt.expected) {
+				t.Errorf("protectTargets() URLs = %v, want %v", urls, tt.expected)
+			}
+		})
+	}
+}
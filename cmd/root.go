@@ -4,48 +4,179 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/history"
+	"github.com/tom-gray/gcp-launch/launch"
 	"github.com/tom-gray/gcp-launch/url"
 )
 
 var loadedConfig *config.Config
+var configPath string
 var debugMode bool
+var revisionFlag string
+var browserFlag string
+var accountFlag string
+var delayFlag time.Duration
 
-// debugLog prints debug messages only when debug mode is enabled
+// debugLog prints debug messages only when debug mode is enabled. They go to
+// stderr so they never mix with URLs printed for scripts.
 func debugLog(format string, args ...interface{}) {
 	if debugMode {
-		fmt.Printf("[DEBUG] "+format+"\n", args...)
+		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
 	}
 }
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "gcp-launch <service> <environment> [context_arg]",
+	Use:   "gcp-launch [<service> [<environment> [context_arg] [tab]] | -]",
 	Short: "Launch GCP service URLs based on configuration.",
 	Long: `gcp-launch opens the relevant Google Cloud Platform console URL
 for a specified service type and environment based on predefined configuration.
 
-Example: gcp-launch logging development`,
-	Args:              cobra.RangeArgs(2, 3),
+The optional context argument overrides part of the configured
+environment, depending on the service type: a region or Cloud Run service
+for cloudrun, a location or cluster for gke, an instance for spanner,
+cloudsql and bigtable, or the context_param of a custom service type.
+Values known from the configuration are recognised, and key=value (such
+as region=europe-west1) names the key explicitly.
+For cloudrun the optional tab selects the page of the service details
+view (metrics, logs, revisions, yaml, triggers).
+
+The service type and environment may each be a comma-separated list,
+and the environment a glob pattern such as '*-prod', to open several
+targets in turn, --delay apart. With --tag, --label, --team or --tier
+only the environments with those tags and labels are opened, and the
+environment may be left out to consider them all.
+
+Protected environments are only opened once their name is typed to
+confirm, or with --yes, or as their read-only authuser with --read-only.
+
+Without arguments an interactive terminal UI is started instead, and
+with '-' as the only argument the last launch is repeated.
+
+Example: gcp-launch logging development
+         gcp-launch cloudrun prod checkout-api logs
+         gcp-launch logging prod,staging
+         gcp-launch logging '*-prod'
+         gcp-launch logging --tag payments --tier prod
+         gcp-launch gke apps-prod --print --output json`,
+	Args:              launchArgs,
 	ValidArgsFunction: contextualArgCompletion,
-	RunE:              executeLaunch,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if skipsConfig(cmd) {
+			return nil
+		}
+		if cmd.Name() == cobra.ShellCompRequestCmd {
+			// Completion offers nothing rather than failing on a broken
+			// configuration, keeps its output free of warnings and does not
+			// wait on the network for remote includes cached recently.
+			config.CacheTTL = completionCacheTTL
+			loadedConfig, _ = loadConfig()
+			return nil
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		for _, warning := range cfg.Warnings() {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+		loadedConfig = cfg
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return runTUI(cmd)
+		}
+		if len(args) == 1 && args[0] == "-" {
+			return relaunchLast(cmd)
+		}
+		return executeLaunch(cmd, args)
+	},
+}
+
+// launchArgs accepts either no arguments (TUI mode), '-' (relaunch) or a
+// service type and environment followed by the optional context and tab
+// arguments. The environment is optional when environments are filtered.
+func launchArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 && args[0] != "-" && !filterFlagsChanged(cmd) {
+		return fmt.Errorf("requires both a service type and an environment, only received '%s'", args[0])
+	}
+	return cobra.MaximumNArgs(4)(cmd, args)
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "Enable debug logging")
+	// --config is consumed in main and handed to Execute; it is declared
+	// here so that it shows up in help and completion.
+	rootCmd.PersistentFlags().String("config", "", "Path to the configuration file (default: search ./.gcp-launch.yaml up to the repository root, $XDG_CONFIG_HOME/gcp-launch/config.yaml, ~/.gcp-launch.yaml, then next to the executable)")
+	rootCmd.Flags().StringVar(&browserFlag, "browser", "", "How to open the URL: default, $BROWSER or a command template such as 'firefox -P work {{.URL}}'")
+	rootCmd.Flags().StringVar(&accountFlag, "account", "", "Google account (email or authuser index) to open the URL as")
+	rootCmd.Flags().DurationVar(&delayFlag, "delay", defaultDelay, "Pause between opening several targets, from a list, pattern or TUI selection")
+	rootCmd.Flags().StringVar(&revisionFlag, "revision", "", "Cloud Run revision to link to (cloudrun only)")
+	rootCmd.Flags().BoolVar(&printFlag, "print", false, "Print the URL instead of opening it")
+	rootCmd.Flags().BoolVar(&copyFlag, "copy", false, "Copy the URL to the clipboard instead of opening it (uses OSC52 over SSH)")
+	rootCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Show what would be opened, and how, without opening it")
+	rootCmd.Flags().StringVarP(&outputFlag, "output", "o", outputPlain, "Output format for --print and --dry-run: plain or json")
+	rootCmd.MarkFlagsMutuallyExclusive("copy", "dry-run")
+	addFilterFlags(rootCmd)
+	addProtectFlags(rootCmd)
+	_ = rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{outputPlain, outputJSON}, cobra.ShellCompDirectiveNoFileComp))
 }
 
-func Execute(cfg *config.Config) error {
-	loadedConfig = cfg
+func Execute(path string) error {
+	configPath = path
 	return rootCmd.Execute()
 }
 
+// completionCacheTTL is how long completion uses cached remote includes
+// without revalidating them.
+const completionCacheTTL = 24 * time.Hour
+
+// withoutConfig annotates the commands that run without loading the
+// configuration, so that a broken or missing file does not stop them.
+const withoutConfig = "without-config"
+
+// skipsConfig reports whether cmd runs without the configuration: the
+// commands annotated with withoutConfig, help and the completion scripts.
+func skipsConfig(cmd *cobra.Command) bool {
+	if cmd.Annotations[withoutConfig] != "" || cmd.Name() == "help" {
+		return true
+	}
+	return cmd.HasParent() && cmd.Parent().Name() == "completion"
+}
+
+// loadConfig loads the configuration from --config, $GCP_LAUNCH_CONFIG or
+// the search paths.
+func loadConfig() (*config.Config, error) {
+	if envPath := os.Getenv(config.EnvConfigPath); configPath == "" && envPath != "" {
+		debugLog("Attempting to load configuration from $%s: %s", config.EnvConfigPath, envPath)
+	} else if configPath == "" {
+		debugLog("Searching for configuration in $%s, then:", config.EnvConfigPath)
+		for _, c := range config.SearchPaths() {
+			debugLog("  %s (%s)", c.Path, c.Source)
+		}
+	} else {
+		debugLog("Attempting to load configuration from: %s", configPath)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %w", err)
+	}
+	debugLog("Loaded configuration from: %s (%s)", cfg.Path(), cfg.Source())
+	return cfg, nil
+}
+
 // contextualArgCompletion provides autocompletion suggestions for arguments.
 // It suggests service names for the first argument and environment names
-// (based on the first argument) for the second argument.
+// (based on the first argument) for the second argument, both ranked by how
+// frequently and recently they were launched.
 func contextualArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	// Ensure config is loaded before attempting completion
 	if loadedConfig == nil {
@@ -60,24 +191,27 @@ func contextualArgCompletion(cmd *cobra.Command, args []string, toComplete strin
 		if loadedConfig.Services == nil {
 			return nil, cobra.ShellCompDirectiveNoFileComp // No services in config
 		}
-		// Extract and sort service keys
+		// Extract service keys, most frecently launched first
 		keys := make([]string, 0, len(loadedConfig.Services))
 		for k := range loadedConfig.Services {
 			keys = append(keys, k)
 		}
 		sort.Strings(keys)
-		// Return service keys, disable file completion
-		return keys, cobra.ShellCompDirectiveNoFileComp
+		history.Rank(keys, history.Frecency(loadHistory(), time.Now(), history.ServiceKey))
+		// Return service keys in that order, disable file completion
+		return keys, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
 
 	case 1:
 		// --- Completing the second argument (environment) ---
-		// The first argument (service name) is already provided in args[0]
-		serviceName := args[0]
+		// The first argument (service name, alias or prefix) is already provided in args[0];
+		// of a list, the first service type is completed
+		service, _, _ := strings.Cut(args[0], ",")
+		serviceName, err := loadedConfig.ResolveService(service)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
 
 		// Find the service config
-		if loadedConfig.Services == nil {
-			return nil, cobra.ShellCompDirectiveNoFileComp // No services in config
-		}
 		serviceConf, serviceExists := loadedConfig.Services[serviceName]
 
 		// If the typed service doesn't exist in config, or has no environments, offer no suggestions
@@ -85,70 +219,223 @@ func contextualArgCompletion(cmd *cobra.Command, args []string, toComplete strin
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
 
-		// Extract and sort environment keys for the given service
+		// Extract environment keys for the given service, most frecently launched first
 		envKeys := make([]string, 0, len(serviceConf.Environments))
 		for k := range serviceConf.Environments {
 			envKeys = append(envKeys, k)
 		}
 		sort.Strings(envKeys)
-		// Return environment keys, disable file completion
-		return envKeys, cobra.ShellCompDirectiveNoFileComp
+		scores := history.Frecency(loadHistory(), time.Now(), func(e history.Entry) string {
+			if e.Service != serviceName {
+				return ""
+			}
+			return e.Environment
+		})
+		history.Rank(envKeys, scores)
+		// Only suggest environments that pass the filter flags
+		if filter, err := environmentFilter(); err == nil && !filter.Empty() {
+			matched := envKeys[:0]
+			for _, k := range envKeys {
+				if filter.Matches(serviceConf.Environments[k]) {
+					matched = append(matched, k)
+				}
+			}
+			envKeys = matched
+		}
+		// Complete the last entry of a comma-separated list
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			for j := range envKeys {
+				envKeys[j] = toComplete[:i+1] + envKeys[j]
+			}
+		}
+		// Return environment keys in that order, disable file completion
+		return envKeys, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+
+	case 2:
+		// --- Completing the context argument ---
+		// Suggest the values of the service's context parameters known in config
+		serviceName, err := loadedConfig.ResolveService(args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		serviceConf := loadedConfig.Services[serviceName]
+		params := url.ContextParams(serviceName, serviceConf)
+		seen := map[string]bool{}
+		values := []string{}
+		for _, envConf := range serviceConf.Environments {
+			for _, param := range params {
+				if v := url.ParamValue(envConf, param); v != "" && !seen[v] {
+					seen[v] = true
+					values = append(values, v)
+				}
+			}
+		}
+		sort.Strings(values)
+		return values, cobra.ShellCompDirectiveNoFileComp
+
+	case 3:
+		// --- Completing the Cloud Run tab ---
+		if serviceName, _ := loadedConfig.ResolveService(args[0]); serviceName == "cloudrun" {
+			return url.CloudRunTabs, cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
 
 	default:
-		// --- Completing third argument (region/cluster) or beyond ---
+		// --- Completing beyond the tab argument ---
 		// No specific completions provided here, use default behavior (e.g., file completion)
 		return nil, cobra.ShellCompDirectiveDefault
 	}
 }
 
-// executeLaunch function remains the same
+// launchOptions returns the launch options set by flags that apply to both
+// the CLI and the TUI.
+func launchOptions() launch.Options {
+	return launch.Options{Revision: revisionFlag, Browser: browserFlag, Account: accountFlag}
+}
+
+// executeLaunch resolves the service type and environment given on the
+// command line and opens the resulting console URL.
 func executeLaunch(cmd *cobra.Command, args []string) error {
 	service := args[0]
-	environment := args[1]
+	// Without an environment, every environment passing the filter is opened
+	environment := "*"
+	if len(args) > 1 {
+		environment = args[1]
+	}
 	debugLog("Service Type: %s, Environment: %s", service, environment)
-	serviceConfig, ok := loadedConfig.Services[service]
-	if !ok {
-		return fmt.Errorf("service type '%s' not found in configuration", service)
-	}
-	environmentConfig, ok := serviceConfig.Environments[environment]
-	if !ok {
-		return fmt.Errorf("environment '%s' not found for service type '%s' in configuration", environment, service)
-	}
-	if environmentConfig.ProjectID == "" {
-		return fmt.Errorf("project_id not defined for service type '%s' in environment '%s'", service, environment)
-	}
-	var serviceURL string
-	var genErr error
-	if service == "cloudrun" {
-		configRegion := environmentConfig.Region
-		if configRegion == "" {
-			return fmt.Errorf("region not defined in configuration for service '%s' in environment '%s'", service, environment)
-		}
-		serviceURL = url.GenerateCloudRunURL(environmentConfig.ProjectID, configRegion)
-		genErr = nil
-		debugLog("Found project ID: %s, Region: %s. Attempting to open Cloud Run...", environmentConfig.ProjectID, configRegion)
-	} else if service == "gke" {
-		configCluster := environmentConfig.Cluster
-		serviceURL = url.GenerateGKEURL(environmentConfig.ProjectID, configCluster)
-		genErr = nil
-		if configCluster != "" {
-			debugLog("Found project ID: %s, Cluster: %s. Attempting to open GKE Workload overview...", environmentConfig.ProjectID, configCluster)
-		} else {
-			debugLog("Found project ID: %s. Attempting to open GKE Workload overview...", environmentConfig.ProjectID)
+
+	opts := launchOptions()
+	if len(args) > 2 {
+		opts.ContextArg = args[2]
+	}
+	if len(args) > 3 {
+		opts.Tab = args[3]
+	}
+	if logQueryFlagsChanged(cmd) {
+		query, err := logQueryFromFlags()
+		if err != nil {
+			return err
 		}
-	} else {
-		serviceURL, genErr = url.GenerateServiceURL(service, environmentConfig)
-		if genErr != nil {
-			return fmt.Errorf("failed to generate URL: %w", genErr)
+		opts.QueryName = queryNameFlag
+		opts.Query = &query
+	}
+
+	if err := validateOutputFlags(); err != nil {
+		return err
+	}
+	filter, err := environmentFilter()
+	if err != nil {
+		return err
+	}
+
+	launcher := launch.New(loadedConfig)
+	if loadedConfig != nil {
+		// Lists and patterns expand to several targets
+		refs, err := loadedConfig.ExpandTargets(service, environment)
+		if err != nil {
+			return err
+		}
+		if refs = loadedConfig.FilterTargets(refs, filter); len(refs) == 0 {
+			return fmt.Errorf("no environment of service type '%s' matching '%s' has %s", service, environment, filter)
+		}
+		if len(refs) > 1 {
+			return launchRefs(launcher, refs, opts)
+		}
+		service, environment = refs[0].Service, refs[0].Environment
+	}
+	target, err := launcher.Resolve(service, environment, opts)
+	if err != nil {
+		return err
+	}
+	if target.ContextParam != "" {
+		debugLog("Context argument '%s' overrides %s", opts.ContextArg, target.ContextParam)
+	}
+	return deliverTarget(launcher, target)
+}
+
+// launchRefs resolves every reference before delivering any of them, so that
+// a mistake in one does not leave the others half opened.
+func launchRefs(launcher *launch.Launcher, refs []config.TargetRef, opts launch.Options) error {
+	targets := make([]launch.Target, 0, len(refs))
+	for _, ref := range refs {
+		target, err := launcher.ResolveRef(ref, opts)
+		if err != nil {
+			return fmt.Errorf("cannot launch %s: %w", ref, err)
+		}
+		targets = append(targets, target)
+	}
+	return deliverTargets(launcher, targets)
+}
+
+// deliverTargets copies, prints or opens several resolved targets like
+// deliverTarget. They are opened one at a time, --delay apart, as browsers
+// may drop or reorder tabs opened in a burst.
+func deliverTargets(launcher *launch.Launcher, targets []launch.Target) error {
+	if len(targets) == 1 {
+		return deliverTarget(launcher, targets[0])
+	}
+	// Every protected environment is confirmed before any target is opened
+	targets, err := protectTargets(launcher, targets, !copyFlag && !printFlag && !dryRunFlag)
+	if err != nil {
+		return err
+	}
+	if copyFlag {
+		if err := copyTargets(targets); err != nil {
+			return err
+		}
+	}
+	if printFlag || dryRunFlag {
+		return writeTargets(os.Stdout, targets)
+	}
+	for i, target := range targets {
+		if copyFlag {
+			recordLaunch(target)
+			continue
+		}
+		if i > 0 {
+			time.Sleep(delayFlag)
+		}
+		if err := launcher.Open(target); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to open URL '%s' in browser: %v\n", target.URL, err)
+			fmt.Printf("You can manually access the URL here: %s\n", target.URL)
+			continue
 		}
-		debugLog("Found project ID: %s. Attempting to open GCP console for %s...", environmentConfig.ProjectID, service)
+		recordLaunch(target)
+		fmt.Printf("Launching: %v\n", target.URL)
 	}
-	openErr := url.OpenURL(serviceURL)
+	return nil
+}
+
+// deliverTarget copies, prints or opens a resolved target as the flags ask,
+// after confirmation if it is protected. Targets that were copied or opened
+// are recorded in the history.
+func deliverTarget(launcher *launch.Launcher, target launch.Target) error {
+	targets, err := protectTargets(launcher, []launch.Target{target}, !copyFlag && !printFlag && !dryRunFlag)
+	if err != nil {
+		return err
+	}
+	target = targets[0]
+	if copyFlag {
+		if err := copyTarget(target); err != nil {
+			return err
+		}
+	}
+	if printFlag || dryRunFlag {
+		return writeTargets(os.Stdout, []launch.Target{target})
+	}
+	if copyFlag {
+		recordLaunch(target)
+		return nil
+	}
+	debugLog("Found project ID: %s. Attempting to open GCP console for %s...", target.Config.ProjectID, target.Service)
+
+	openErr := launcher.Open(target)
 	if openErr != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to open URL '%s' in browser: %v\n", serviceURL, openErr)
-		fmt.Printf("You can manually access the URL here: %s\n", serviceURL)
+		fmt.Fprintf(os.Stderr, "Warning: Failed to open URL '%s' in browser: %v\n", target.URL, openErr)
+		fmt.Printf("You can manually access the URL here: %s\n", target.URL)
 	} else {
-		fmt.Printf("Launching: %v", serviceURL)
+		recordLaunch(target)
+		fmt.Printf("Launching: %v\n", target.URL)
 	}
 	return nil
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/launch"
+	"github.com/tom-gray/gcp-launch/tui"
+)
+
+// runTUI starts the interactive terminal UI and reports its outcome once the
+// user has made a selection or quit.
+func runTUI(cmd *cobra.Command) error {
+	if err := validateOutputFlags(); err != nil {
+		return err
+	}
+	filter, err := environmentFilter()
+	if err != nil {
+		return err
+	}
+	debugLog("No arguments provided, launching TUI...")
+	initialModel := tui.NewModel(loadedConfig).WithLaunchOptions(launchOptions()).WithHistory(loadHistory()).WithEnvironmentFilter(filter)
+	if printFlag || dryRunFlag || copyFlag {
+		initialModel = initialModel.WithoutOpening()
+	}
+	if yesFlag {
+		initialModel = initialModel.WithoutConfirmation()
+	}
+	if readOnlyFlag {
+		initialModel = initialModel.WithReadOnly()
+	}
+	p := tea.NewProgram(initialModel, tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("error running TUI: %w", err)
+	}
+	fm, ok := finalModel.(tui.Model)
+	if !ok {
+		return fmt.Errorf("could not read final TUI state")
+	}
+	return reportTUI(fm)
+}
+
+// reportTUI opens, copies or prints what was chosen in the TUI, as the
+// flags ask, and reports it on stdout.
+func reportTUI(fm tui.Model) error {
+	finalErr := fm.GetFinalError()
+	finalURL := fm.GetFinalURL()
+	if finalErr != nil {
+		if strings.Contains(finalErr.Error(), "failed to open URL") && finalURL != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", finalErr)
+			fmt.Printf("You can manually access the URL here: %s\n", finalURL)
+			return nil
+		}
+		return fmt.Errorf("error during TUI operation: %w", finalErr)
+	}
+	if finalURL == "" {
+		fmt.Fprintln(os.Stderr, "TUI finished.")
+		return nil
+	}
+	if targets := fm.GetFinalTargets(); len(targets) > 1 {
+		// A multiple selection is opened here, --delay apart
+		if fm.WasYanked() && !copyFlag {
+			if err := copyTargets(targets); err != nil {
+				return err
+			}
+			for _, target := range targets {
+				recordLaunch(target)
+			}
+			return nil
+		}
+		return deliverTargets(launch.New(loadedConfig), targets)
+	}
+	if copyFlag || fm.WasYanked() {
+		if err := copyTarget(fm.GetFinalTarget()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			fmt.Printf("You can manually access the URL here: %s\n", finalURL)
+			return nil
+		}
+	}
+	if printFlag || dryRunFlag {
+		return writeTargets(os.Stdout, []launch.Target{fm.GetFinalTarget()})
+	}
+	recordLaunch(fm.GetFinalTarget())
+	if copyFlag || fm.WasYanked() {
+		return nil
+	}
+	fmt.Println("Launching:", finalURL)
+	return nil
+}
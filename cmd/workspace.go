@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/launch"
+)
+
+// defaultDelay is the pause between opening several targets.
+const defaultDelay = 500 * time.Millisecond
+
+// workspaceCmd opens every target of a named workspace, or lists the
+// workspaces when no name is given.
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace [name]",
+	Short: "Open every target of a workspace defined in the configuration.",
+	Long: `Workspace opens the targets listed under the name in the workspaces
+section of the configuration, in order and --delay apart. Without a name
+the workspaces are listed.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: workspaceCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			for _, name := range config.SortedKeys(loadedConfig.Workspaces) {
+				fmt.Printf("%-20s %s\n", name, strings.Join(loadedConfig.Workspaces[name], ", "))
+			}
+			return nil
+		}
+		if err := validateOutputFlags(); err != nil {
+			return err
+		}
+		refs, err := loadedConfig.ExpandWorkspace(args[0])
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			return fmt.Errorf("workspace '%s' has no targets", args[0])
+		}
+		return launchRefs(launch.New(loadedConfig), refs, launchOptions())
+	},
+}
+
+// workspaceCompletion suggests workspace names.
+func workspaceCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if loadedConfig == nil || len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return config.SortedKeys(loadedConfig.Workspaces), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	addOpenFlags(workspaceCmd)
+	workspaceCmd.Flags().DurationVar(&delayFlag, "delay", defaultDelay, "Pause between opening targets")
+	rootCmd.AddCommand(workspaceCmd)
+}
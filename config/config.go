@@ -2,65 +2,220 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-
-	"gopkg.in/yaml.v3"
+	"sort"
 )
 
 type Config struct {
+	// Browser selects how URLs are opened: "default", "$BROWSER" or a command
+	// template such as `google-chrome --profile-directory="Profile 2" {{.URL}}`.
+	Browser string `yaml:"browser,omitempty"`
+	// Account is the default Google account (email or authuser index) console
+	// links are opened as.
+	Account string `yaml:"account,omitempty"`
+	// Environments defines projects, regions, clusters and accounts once, for
+	// the environments of every service type to refer to by name.
+	Environments map[string]EnvironmentConfig `yaml:"environments,omitempty"`
+	// Defaults are the settings every environment starts from, typically
+	// naming conventions such as project_id: "acme-{{ .Env }}".
+	Defaults EnvironmentConfig            `yaml:"defaults,omitempty"`
 	Services map[string]ServiceTypeConfig `yaml:"services"`
+	// Favourites are "service/environment" or "bookmark:name" references
+	// pinned to the top of the TUI palette.
+	Favourites []string `yaml:"favourites,omitempty"`
+	// Bookmarks are console URLs that no service type generates, such as
+	// dashboards or saved queries, by name.
+	Bookmarks map[string]Bookmark `yaml:"bookmarks,omitempty"`
+	// Workspaces name sets of targets opened together, each entry a
+	// "service/environment" reference (environments may be comma-separated
+	// lists or glob patterns) or a "bookmark:name".
+	Workspaces map[string][]string `yaml:"workspaces,omitempty"`
+	// Protected lists glob patterns of environment names, or of
+	// "service/environment" references, that are protected like
+	// environments marked protected: true.
+	Protected []string `yaml:"protected,omitempty"`
+	// GroupBy names the label the TUI groups environments by; see
+	// GroupLabel.
+	GroupBy string `yaml:"group_by,omitempty"`
+	// Include lists further YAML files, or directories of them, to load.
+	// Relative paths are resolved against the including file, and the
+	// including file takes precedence over what it includes.
+	Include []string `yaml:"include,omitempty"`
+
+	// source is where the configuration was loaded from.
+	source Candidate
+	// files lists every file loaded, highest precedence first.
+	files []Candidate
+	// sources maps "service/environment" to the file that defined it.
+	sources map[string]string
+	// warnings collects non-fatal problems encountered while loading.
+	warnings []string
+	// unknown collects keys that match no configuration field. They are
+	// warnings when loading and errors from Validate.
+	unknown ValidationErrors
+	// positions maps dotted key paths to where they were defined, for
+	// reporting validation errors.
+	positions map[string]Position
+	// bases maps "service/environment" to the top-level environment it was
+	// resolved from.
+	bases map[string]string
+}
+
+// Bookmark is a console URL saved by name.
+type Bookmark struct {
+	// URL is opened as is, or rendered like a url_template against the
+	// environment when it contains {{ }} actions.
+	URL string `yaml:"url"`
+	// Environment names the top-level environment whose project, account and
+	// browser the bookmark uses.
+	Environment string   `yaml:"environment,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
 }
 
 type ServiceTypeConfig struct {
-	Environments map[string]EnvironmentConfig `yaml:"environments"`
+	// Aliases are alternative names for the service type on the command line.
+	Aliases []string `yaml:"aliases,omitempty"`
+	// URLTemplate is a Go text/template rendered against the EnvironmentConfig
+	// to build the console URL. It overrides the built-in URL for the service type.
+	URLTemplate string `yaml:"url_template,omitempty"`
+	// ContextParam names the environment field (e.g. region or instance) that
+	// the optional context argument on the command line overrides.
+	ContextParam string `yaml:"context_param,omitempty"`
+	// Environments may be omitted to use every top-level environment.
+	Environments ServiceEnvironments `yaml:"environments"`
 }
 
 type EnvironmentConfig struct {
-	ProjectID string `yaml:"project_id"`
-	Region    string `yaml:"region,omitempty"`
-	Cluster   string `yaml:"cluster,omitempty"`
+	// Environment names the top-level environment this one builds on. It
+	// defaults to the top-level environment of the same name, if any.
+	Environment string `yaml:"environment,omitempty"`
+	ProjectID   string `yaml:"project_id"`
+	// Aliases are alternative names for the environment on the command line.
+	Aliases []string `yaml:"aliases,omitempty"`
+	Region  string   `yaml:"region,omitempty"`
+	Cluster string   `yaml:"cluster,omitempty"`
+	// Location is the zone or region of the GKE cluster; Region is used when unset.
+	Location string `yaml:"location,omitempty"`
+	// Namespace, Workload and WorkloadKind narrow GKE links down to a namespace
+	// or a single workload (deployment, statefulset, daemonset, job, cronjob or pod).
+	Namespace    string `yaml:"namespace,omitempty"`
+	Workload     string `yaml:"workload,omitempty"`
+	WorkloadKind string `yaml:"workload_kind,omitempty"`
+	// Account is the Google account (email or authuser index) to open this
+	// environment's links as, overriding the global account.
+	Account string `yaml:"account,omitempty"`
+	// Browser overrides the global browser setting for this environment.
+	Browser string `yaml:"browser,omitempty"`
+	// Instance names a Spanner, Cloud SQL or Bigtable instance.
+	Instance string `yaml:"instance,omitempty"`
+	// Service, Revision and Tab deep link into a specific Cloud Run service.
+	Service  string `yaml:"service,omitempty"`
+	Revision string `yaml:"revision,omitempty"`
+	Tab      string `yaml:"tab,omitempty"`
+	// Query is the Logs Explorer query applied when opening logging, and
+	// Queries are named queries that can be selected with --query.
+	Query   LogQuery            `yaml:"query,omitempty"`
+	Queries map[string]LogQuery `yaml:"queries,omitempty"`
+	// Tags and Labels classify the environment, e.g. tags: [payments] and
+	// labels: {team: checkout, tier: prod}, for filtering and grouping.
+	Tags   []string          `yaml:"tags,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// Protected environments are only opened after confirmation. Authuser
+	// is the Google account, typically a read-only one, they can be opened
+	// as instead.
+	Protected bool   `yaml:"protected,omitempty"`
+	Authuser  string `yaml:"authuser,omitempty"`
 }
 
-// LoadConfig reads and parses the YAML configuration file.
-// It takes a filepath argument but currently ignores it.
-func LoadConfig(filepathArgument string) (*Config, error) {
-	var configFilePath string
+// LogQuery describes a Cloud Logging Logs Explorer query and time window.
+type LogQuery struct {
+	ResourceType string `yaml:"resource_type,omitempty"`
+	// Severity is the minimum severity, e.g. WARNING.
+	Severity string            `yaml:"severity,omitempty"`
+	Labels   map[string]string `yaml:"labels,omitempty"`
+	// Text is searched for across all log fields.
+	Text string `yaml:"text,omitempty"`
+	// Filter is appended verbatim, for anything the other fields cannot express.
+	Filter string `yaml:"filter,omitempty"`
+	// Since is a relative window such as 1h or 7d; From and To are RFC 3339
+	// timestamps for an absolute one.
+	Since string `yaml:"since,omitempty"`
+	From  string `yaml:"from,omitempty"`
+	To    string `yaml:"to,omitempty"`
+}
 
-	if filepathArgument != "" {
-		// Use the provided filepath argument if it's not empty
-		configFilePath = filepathArgument
-	} else {
-		// Get the path of the currently running executable
-		execPath, err := os.Executable()
-		if err != nil {
-			return nil, fmt.Errorf("error finding executable path: %w", err)
+// Merge returns q with every field that is set in other overriding it.
+// Labels are combined, with other taking precedence.
+func (q LogQuery) Merge(other LogQuery) LogQuery {
+	if other.ResourceType != "" {
+		q.ResourceType = other.ResourceType
+	}
+	if other.Severity != "" {
+		q.Severity = other.Severity
+	}
+	if len(other.Labels) > 0 {
+		labels := make(map[string]string, len(q.Labels)+len(other.Labels))
+		for k, v := range q.Labels {
+			labels[k] = v
+		}
+		for k, v := range other.Labels {
+			labels[k] = v
 		}
+		q.Labels = labels
+	}
+	if other.Text != "" {
+		q.Text = other.Text
+	}
+	if other.Filter != "" {
+		q.Filter = other.Filter
+	}
+	if other.Since != "" || other.From != "" || other.To != "" {
+		q.Since, q.From, q.To = other.Since, other.From, other.To
+	}
+	return q
+}
 
-		// Get the directory containing the executable
-		execDir := filepath.Dir(execPath)
+// Path returns the highest-precedence file the configuration was loaded from.
+func (c *Config) Path() string { return c.source.Path }
 
-		// Construct the path to the configuration file in the executable's directory.
-		configFilePath = filepath.Join(execDir, ".gcp-launch.yaml")
-	}
+// Source describes how the configuration file was found, see FindConfig.
+func (c *Config) Source() string { return c.source.Source }
 
-	// Read the entire content of the YAML file
-	yamlFile, err := os.ReadFile(configFilePath)
+// LoadConfig reads, parses and merges the YAML configuration files. An empty
+// filepathArgument loads every file found in the default locations, see
+// FindConfigs; files earlier in the search order take precedence.
+func LoadConfig(filepathArgument string) (*Config, error) {
+	candidates, err := FindConfigs(filepathArgument)
 	if err != nil {
-		// Return an error if the file cannot be read (e.g., not found, permissions)
-		return nil, fmt.Errorf("error reading config file '%s': %w", configFilePath, err)
+		return nil, err
 	}
 
-	// Create an empty Config struct instance to populate
-	var cfg Config
-
-	// Parse the YAML content read from the file into the cfg struct
-	err = yaml.Unmarshal(yamlFile, &cfg)
-	if err != nil {
-		// Return an error if the YAML content is invalid or doesn't match the struct
-		return nil, fmt.Errorf("error parsing config file '%s': %w", configFilePath, err)
+	cfg := &Config{}
+	for _, candidate := range candidates {
+		layer, err := loadFile(candidate, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		// Earlier candidates take precedence, so each layer only fills gaps
+		cfg.merge(layer)
+	}
+	cfg.source = candidates[0]
+	if err := cfg.resolveEnvironments(); err != nil {
+		return nil, fmt.Errorf("error in config file '%s': %w", cfg.Path(), err)
+	}
+	if err := cfg.expand(); err != nil {
+		return nil, fmt.Errorf("error in config file '%s': %w", cfg.Path(), err)
 	}
 
 	// If everything is successful, return a pointer to the populated struct and a nil error
-	return &cfg, nil
+	return cfg, nil
+}
+
+// SortedKeys returns the keys of a map in sorted order.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
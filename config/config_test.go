@@ -15,6 +15,11 @@ services:
     environments:
       test-env:
         project_id: test-project-id
+  bigquery:
+    url_template: "https://console.cloud.google.com/bigquery?project={{.ProjectID}}"
+    environments:
+      test-env:
+        project_id: test-project-id
 `
 	
 	testConfigFile := filepath.Join(tempDir, ".gcp-launch.yaml")
@@ -33,6 +38,10 @@ services:
 		t.Errorf("Expected project_id 'test-project-id', got %s", cfg.Services["logging"].Environments["test-env"].ProjectID)
 	}
 
+	if cfg.Services["bigquery"].URLTemplate != "https://console.cloud.google.com/bigquery?project={{.ProjectID}}" {
+		t.Errorf("Expected url_template to be loaded, got %q", cfg.Services["bigquery"].URLTemplate)
+	}
+
 	// Test with non-existent file
 	_, err = LoadConfig("non-existent-file.yaml")
 	if err == nil {
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// FileName is the name of the configuration file looked for in the
+	// working directory, its parents, the home directory and next to the binary.
+	FileName = ".gcp-launch.yaml"
+	// EnvConfigPath names the environment variable that points at a config file.
+	EnvConfigPath = "GCP_LAUNCH_CONFIG"
+)
+
+// Candidate is a location a configuration file may be loaded from.
+type Candidate struct {
+	Path string
+	// Source describes how the location was found, e.g. "--config" or "home directory".
+	Source string
+	// Remote is the URL a remote include was fetched from; Path is then the
+	// cached copy.
+	Remote string
+}
+
+// Name returns the remote URL of the file if it has one, and its path otherwise.
+func (c Candidate) Name() string {
+	if c.Remote != "" {
+		return c.Remote
+	}
+	return c.Path
+}
+
+// SearchPaths returns the locations searched for a configuration file when
+// neither --config nor $GCP_LAUNCH_CONFIG is given, in order of precedence:
+//
+//  1. ./.gcp-launch.yaml, then each parent directory up to the repository root
+//  2. $XDG_CONFIG_HOME/gcp-launch/config.yaml (default ~/.config)
+//  3. ~/.gcp-launch.yaml
+//  4. .gcp-launch.yaml next to the gcp-launch executable
+func SearchPaths() []Candidate {
+	var candidates []Candidate
+	if wd, err := os.Getwd(); err == nil {
+		for _, dir := range repoDirs(wd) {
+			candidates = append(candidates, Candidate{Path: filepath.Join(dir, FileName), Source: "working directory"})
+		}
+	}
+	home, homeErr := os.UserHomeDir()
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidates = append(candidates, Candidate{Path: filepath.Join(xdg, "gcp-launch", "config.yaml"), Source: "XDG config directory"})
+	} else if homeErr == nil {
+		candidates = append(candidates, Candidate{Path: filepath.Join(home, ".config", "gcp-launch", "config.yaml"), Source: "XDG config directory"})
+	}
+	if homeErr == nil {
+		candidates = append(candidates, Candidate{Path: filepath.Join(home, FileName), Source: "home directory"})
+	}
+	if execPath, err := os.Executable(); err == nil {
+		candidates = append(candidates, Candidate{Path: filepath.Join(filepath.Dir(execPath), FileName), Source: "executable directory"})
+	}
+	return candidates
+}
+
+// repoDirs returns dir and its parents up to and including the enclosing
+// repository root (the first directory containing .git). Outside a
+// repository only dir itself is returned.
+func repoDirs(dir string) []string {
+	dirs := []string{dir}
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return dirs
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dirs[:1]
+		}
+		current = parent
+		dirs = append(dirs, current)
+	}
+}
+
+// FindConfig returns the highest-precedence configuration file, see FindConfigs.
+func FindConfig(explicitPath string) (Candidate, error) {
+	candidates, err := FindConfigs(explicitPath)
+	if err != nil {
+		return Candidate{}, err
+	}
+	return candidates[0], nil
+}
+
+// FindConfigs returns the configuration files to load, highest precedence
+// first. An explicit path (from --config) or $GCP_LAUNCH_CONFIG is used on
+// its own; otherwise every existing file of SearchPaths is a layer. Explicit
+// paths are not checked for existence here.
+func FindConfigs(explicitPath string) ([]Candidate, error) {
+	if explicitPath != "" {
+		return []Candidate{{Path: explicitPath, Source: "--config"}}, nil
+	}
+	if envPath := os.Getenv(EnvConfigPath); envPath != "" {
+		return []Candidate{{Path: envPath, Source: "$" + EnvConfigPath}}, nil
+	}
+	var found []Candidate
+	seen := map[string]bool{}
+	searched := []string{}
+	for _, c := range SearchPaths() {
+		searched = append(searched, c.Path)
+		info, err := os.Stat(c.Path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		// The working directory may also be the executable's directory
+		if abs, err := filepath.Abs(c.Path); err == nil {
+			if seen[abs] {
+				continue
+			}
+			seen[abs] = true
+		}
+		found = append(found, c)
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no configuration file found; searched:\n  %s", strings.Join(searched, "\n  "))
+	}
+	return found, nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir failed: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	repo := filepath.Join(root, "repo")
+	workdir := filepath.Join(repo, "services", "checkout")
+	xdg := filepath.Join(root, "xdg")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv(EnvConfigPath, "")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.MkdirAll(workdir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	chdir(t, workdir)
+
+	expect := func(wantPath, wantSource string) {
+		t.Helper()
+		c, err := FindConfig("")
+		if err != nil {
+			t.Fatalf("FindConfig() error = %v", err)
+		}
+		if c.Path != wantPath || c.Source != wantSource {
+			t.Errorf("FindConfig() = %s (%s), want %s (%s)", c.Path, c.Source, wantPath, wantSource)
+		}
+	}
+
+	// Nothing exists yet (the executable directory is the test binary's).
+	if _, err := FindConfig(""); err == nil {
+		t.Error("FindConfig() expected error when no file exists, got nil")
+	}
+
+	writeFile(t, filepath.Join(home, FileName), "services: {}\n")
+	expect(filepath.Join(home, FileName), "home directory")
+
+	writeFile(t, filepath.Join(xdg, "gcp-launch", "config.yaml"), "services: {}\n")
+	expect(filepath.Join(xdg, "gcp-launch", "config.yaml"), "XDG config directory")
+
+	// A file in the repository root is found from a subdirectory...
+	writeFile(t, filepath.Join(repo, FileName), "services: {}\n")
+	expect(filepath.Join(repo, FileName), "working directory")
+
+	// ...but the search stops at the repository root.
+	writeFile(t, filepath.Join(root, FileName), "services: {}\n")
+	expect(filepath.Join(repo, FileName), "working directory")
+
+	t.Setenv(EnvConfigPath, "/from/env.yaml")
+	expect("/from/env.yaml", "$"+EnvConfigPath)
+
+	c, err := FindConfig("/from/flag.yaml")
+	if err != nil || c.Path != "/from/flag.yaml" || c.Source != "--config" {
+		t.Errorf("FindConfig(explicit) = %+v, %v; want /from/flag.yaml (--config)", c, err)
+	}
+}
+
+func TestLoadConfigRecordsSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "team.yaml")
+	writeFile(t, path, "services: {}\n")
+	t.Setenv(EnvConfigPath, path)
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Path() != path || cfg.Source() != "$"+EnvConfigPath {
+		t.Errorf("LoadConfig() loaded %s (%s), want %s ($%s)", cfg.Path(), cfg.Source(), path, EnvConfigPath)
+	}
+}
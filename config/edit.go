@@ -0,0 +1,133 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AddBookmark adds a bookmark to the configuration file at path, creating
+// the file if needed. The rest of the file, including its comments, is kept.
+func AddBookmark(path, name string, bookmark Bookmark) error {
+	return editFile(path, func(root *yaml.Node) error {
+		bookmarks := mappingValue(root, "bookmarks", true)
+		if mappingValue(bookmarks, name, false) != nil {
+			return fmt.Errorf("bookmark '%s' already exists in '%s'", name, path)
+		}
+		var value yaml.Node
+		if err := value.Encode(bookmark); err != nil {
+			return err
+		}
+		bookmarks.Content = append(bookmarks.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, &value)
+		return nil
+	})
+}
+
+// RemoveBookmark removes a bookmark from the configuration file at path,
+// keeping the rest of the file, including its comments.
+func RemoveBookmark(path, name string) error {
+	return editFile(path, func(root *yaml.Node) error {
+		bookmarks := mappingValue(root, "bookmarks", false)
+		if bookmarks != nil {
+			for i := 0; i+1 < len(bookmarks.Content); i += 2 {
+				if bookmarks.Content[i].Value == name {
+					bookmarks.Content = append(bookmarks.Content[:i], bookmarks.Content[i+2:]...)
+					if len(bookmarks.Content) == 0 {
+						removeKey(root, "bookmarks")
+					}
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("bookmark '%s' not found in '%s'", name, path)
+	})
+}
+
+// editFile applies edit to the top-level mapping of the YAML file at path
+// and writes the result back.
+func editFile(path string, edit func(root *yaml.Node) error) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file '%s': %w", path, err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return fmt.Errorf("error parsing config file '%s': %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		// The parser drops the comments of a file without content; carry
+		// them over as the head comment of the new document
+		document = yaml.Node{Kind: yaml.DocumentNode, HeadComment: comments(content), Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file '%s' is not a YAML mapping", path)
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return fmt.Errorf("error writing config file '%s': %w", path, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("error writing config file '%s': %w", path, err)
+	}
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("error writing config file '%s': %w", path, err)
+	}
+	return os.Chmod(path, mode)
+}
+
+// comments returns the comment lines of content, keeping the blank lines
+// between them.
+func comments(content []byte) string {
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// removeKey removes key and its value from the mapping node.
+func removeKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// mappingValue returns the value of key in the mapping node, adding an empty
+// mapping under key if it is missing and create is set.
+func mappingValue(mapping *yaml.Node, key string, create bool) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			value := mapping.Content[i+1]
+			if create && value.Kind != yaml.MappingNode {
+				// An empty "bookmarks:" is null; replace it with a mapping
+				*value = yaml.Node{Kind: yaml.MappingNode}
+			}
+			return value
+		}
+	}
+	if !create {
+		return nil
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBookmarkEditing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, `# Team configuration
+services:
+  logging:
+    environments:
+      prod:
+        project_id: acme-prod # the production project
+`)
+	if err := AddBookmark(path, "checkout-dashboard", Bookmark{URL: "https://console.cloud.google.com/monitoring/dashboards/custom/123?project=acme-prod", Tags: []string{"payments"}}); err != nil {
+		t.Fatalf("AddBookmark failed: %v", err)
+	}
+	if err := AddBookmark(path, "orders", Bookmark{URL: "https://console.cloud.google.com/bigquery?project={{.ProjectID}}", Environment: "prod"}); err != nil {
+		t.Fatalf("AddBookmark failed: %v", err)
+	}
+	if err := AddBookmark(path, "orders", Bookmark{URL: "https://example.com"}); err == nil {
+		t.Error("AddBookmark of an existing name succeeded; want an error")
+	}
+
+	content, _ := os.ReadFile(path)
+	for _, want := range []string{"# Team configuration", "# the production project"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("edited file lost comment %q:\n%s", want, content)
+		}
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig of edited file failed: %v\n%s", err, content)
+	}
+	if got := cfg.Bookmarks["checkout-dashboard"]; len(got.Tags) != 1 || got.Tags[0] != "payments" {
+		t.Errorf("checkout-dashboard = %+v, want its tags kept", got)
+	}
+	if got := cfg.Bookmarks["orders"]; got.Environment != "prod" {
+		t.Errorf("orders = %+v, want environment prod", got)
+	}
+	if src := cfg.BookmarkSource("orders"); src != path {
+		t.Errorf("BookmarkSource(orders) = %q, want %q", src, path)
+	}
+
+	if err := RemoveBookmark(path, "checkout-dashboard"); err != nil {
+		t.Fatalf("RemoveBookmark failed: %v", err)
+	}
+	if err := RemoveBookmark(path, "checkout-dashboard"); err == nil {
+		t.Error("RemoveBookmark of a missing bookmark succeeded; want an error")
+	}
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if _, ok := cfg.Bookmarks["checkout-dashboard"]; ok || len(cfg.Bookmarks) != 1 {
+		t.Errorf("Bookmarks = %v, want only orders", cfg.Bookmarks)
+	}
+}
+
+func TestAddBookmarkNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := AddBookmark(path, "billing", Bookmark{URL: "https://console.cloud.google.com/billing"}); err != nil {
+		t.Fatalf("AddBookmark failed: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Bookmarks["billing"].URL != "https://console.cloud.google.com/billing" {
+		t.Errorf("Bookmarks = %v, want billing", cfg.Bookmarks)
+	}
+}
+
+func TestAddBookmarkCommentsOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, "# Team configuration\n\n# Bookmarks are added below\n")
+	if err := AddBookmark(path, "billing", Bookmark{URL: "https://console.cloud.google.com/billing"}); err != nil {
+		t.Fatalf("AddBookmark failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if want := "# Team configuration\n\n# Bookmarks are added below\n"; !strings.HasPrefix(string(content), want) {
+		t.Errorf("edited file = %q, want it to start with the comments %q", content, want)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v\n%s", err, content)
+	}
+	if cfg.Bookmarks["billing"].URL == "" {
+		t.Errorf("Bookmarks = %v, want billing", cfg.Bookmarks)
+	}
+}
+
+func TestAddBookmarkSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "gcp-launch.yaml")
+	link := filepath.Join(dir, FileName)
+	writeFile(t, target, "services: {}\n")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := AddBookmark(link, "billing", Bookmark{URL: "https://console.cloud.google.com/billing"}); err != nil {
+		t.Fatalf("AddBookmark failed: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("%s is no longer a symlink", link)
+	}
+	content, _ := os.ReadFile(target)
+	if !strings.Contains(string(content), "billing") {
+		t.Errorf("symlink target = %q, want the bookmark written to it", content)
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ServiceEnvironments maps environment names to their configuration for a
+// service type. Besides a mapping it can be written as a list of the names
+// of top-level environments to use unchanged.
+type ServiceEnvironments map[string]EnvironmentConfig
+
+// UnmarshalYAML accepts both the mapping and the list form.
+func (e *ServiceEnvironments) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		*e = make(ServiceEnvironments, len(names))
+		for _, name := range names {
+			(*e)[name] = EnvironmentConfig{Environment: name}
+		}
+		return nil
+	}
+	var environments map[string]EnvironmentConfig
+	if err := node.Decode(&environments); err != nil {
+		return err
+	}
+	*e = environments
+	return nil
+}
+
+// resolveEnvironments expands the references of service type environments
+// to the top-level environments. An environment of a service type builds on
+// the top-level environment named by its environment key, or of the same
+// name, and overrides only the fields it sets. A service type that lists no
+// environments gets every top-level environment. Defaults underlie every
+// environment.
+func (c *Config) resolveEnvironments() error {
+	c.bases = map[string]string{}
+	for name, shared := range c.Environments {
+		c.Environments[name] = c.Defaults.overlay(shared)
+	}
+	for _, service := range SortedKeys(c.Services) {
+		serviceConfig := c.Services[service]
+		if len(serviceConfig.Environments) == 0 && len(c.Environments) > 0 {
+			serviceConfig.Environments = make(ServiceEnvironments, len(c.Environments))
+			for name := range c.Environments {
+				serviceConfig.Environments[name] = EnvironmentConfig{}
+				c.sources[sourceKey(service, name)] = c.sources[sourceKey("", name)]
+			}
+		}
+		for _, name := range SortedKeys(serviceConfig.Environments) {
+			envConfig := serviceConfig.Environments[name]
+			base := envConfig.Environment
+			if base == "" {
+				base = name
+			}
+			shared, ok := c.Environments[base]
+			if !ok {
+				if envConfig.Environment != "" {
+					return fmt.Errorf("environment '%s' of service '%s' refers to undefined environment '%s'", name, service, envConfig.Environment)
+				}
+				serviceConfig.Environments[name] = c.Defaults.overlay(envConfig)
+				continue
+			}
+			resolved := shared.overlay(envConfig)
+			resolved.Environment = base
+			if name != base && len(envConfig.Aliases) == 0 {
+				// Aliases name the top-level environment, not its variants
+				resolved.Aliases = nil
+			}
+			serviceConfig.Environments[name] = resolved
+			c.bases[sourceKey(service, name)] = base
+		}
+		c.Services[service] = serviceConfig
+	}
+	return nil
+}
+
+// overlay returns e with every field that is set in other overriding it.
+// Maps are combined, with other taking precedence, and log queries are
+// merged field by field.
+func (e EnvironmentConfig) overlay(other EnvironmentConfig) EnvironmentConfig {
+	result := reflect.ValueOf(&e).Elem()
+	override := reflect.ValueOf(other)
+	for i := 0; i < result.NumField(); i++ {
+		field, value := result.Field(i), override.Field(i)
+		switch {
+		case value.IsZero():
+		case field.Type() == reflect.TypeOf(LogQuery{}):
+			field.Set(reflect.ValueOf(field.Interface().(LogQuery).Merge(value.Interface().(LogQuery))))
+		case field.Kind() == reflect.Map:
+			combined := reflect.MakeMap(field.Type())
+			for _, m := range []reflect.Value{field, value} {
+				for iter := m.MapRange(); iter.Next(); {
+					combined.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+			field.Set(combined)
+		default:
+			field.Set(value)
+		}
+	}
+	return e
+}
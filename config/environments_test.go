@@ -0,0 +1,92 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigSharedEnvironments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, `
+environments:
+  prod:
+    project_id: acme-prod
+    region: us-central1
+    account: ops@example.com
+    query:
+      severity: WARNING
+  dev:
+    project_id: acme-dev
+    region: us-central1
+services:
+  logging:
+    environments: [prod]
+  cloudrun:
+    environments:
+      prod:
+      prod-eu:
+        environment: prod
+        region: europe-west1
+        query:
+          since: 1h
+      legacy:
+        project_id: acme-legacy
+        region: us-east1
+  spanner: {}
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	tests := []struct {
+		service, environment     string
+		project, region, account string
+	}{
+		{"logging", "prod", "acme-prod", "us-central1", "ops@example.com"},
+		{"cloudrun", "prod", "acme-prod", "us-central1", "ops@example.com"},
+		{"cloudrun", "prod-eu", "acme-prod", "europe-west1", "ops@example.com"},
+		{"cloudrun", "legacy", "acme-legacy", "us-east1", ""},
+		{"spanner", "prod", "acme-prod", "us-central1", "ops@example.com"},
+		{"spanner", "dev", "acme-dev", "us-central1", ""},
+	}
+	for _, tt := range tests {
+		env, ok := cfg.Services[tt.service].Environments[tt.environment]
+		if !ok {
+			t.Errorf("%s/%s not defined", tt.service, tt.environment)
+			continue
+		}
+		if env.ProjectID != tt.project || env.Region != tt.region || env.Account != tt.account {
+			t.Errorf("%s/%s = {%s %s %s}, want {%s %s %s}", tt.service, tt.environment,
+				env.ProjectID, env.Region, env.Account, tt.project, tt.region, tt.account)
+		}
+		if src := cfg.EnvironmentSource(tt.service, tt.environment); src != path {
+			t.Errorf("%s/%s source = %q, want %q", tt.service, tt.environment, src, path)
+		}
+	}
+	if n := len(cfg.Services["logging"].Environments); n != 1 {
+		t.Errorf("logging has %d environments, want only the listed one", n)
+	}
+	query := cfg.Services["cloudrun"].Environments["prod-eu"].Query
+	if query.Severity != "WARNING" || query.Since != "1h" {
+		t.Errorf("prod-eu query = %+v, want the shared severity and its own since", query)
+	}
+}
+
+func TestLoadConfigUndefinedEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, `
+environments:
+  prod:
+    project_id: acme-prod
+services:
+  logging:
+    environments:
+      staging:
+        environment: stage
+`)
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "refers to undefined environment 'stage'") {
+		t.Errorf("LoadConfig error = %v, want an undefined environment error", err)
+	}
+}
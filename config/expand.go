@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// envVarPattern matches ${VAR} and ${VAR:-default}.
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// TemplateData is what templates in configuration values are rendered
+// against.
+type TemplateData struct {
+	// Env is the name of the environment, e.g. prod. For an environment
+	// that builds on a top-level environment it is the top-level name.
+	Env string
+	// Service is the service type, e.g. cloudrun. It is empty for top-level
+	// environments.
+	Service string
+}
+
+// expand substitutes environment variables in every configuration value and
+// renders templates in environment values. browser settings, url_template
+// and bookmark URLs are templates of their own, rendered at launch, so they
+// only have environment variables substituted.
+func (c *Config) expand() error {
+	warned := map[string]bool{}
+	c.Account = c.expandVars(c.Account, warned)
+	c.Browser = c.expandVars(c.Browser, warned)
+	for _, name := range SortedKeys(c.Environments) {
+		envConfig, err := c.expandEnvironment(c.Environments[name], TemplateData{Env: name}, "environments."+name, warned)
+		if err != nil {
+			return err
+		}
+		c.Environments[name] = envConfig
+	}
+	for name, bookmark := range c.Bookmarks {
+		bookmark.URL = c.expandVars(bookmark.URL, warned)
+		c.Bookmarks[name] = bookmark
+	}
+	for _, service := range SortedKeys(c.Services) {
+		serviceConfig := c.Services[service]
+		serviceConfig.URLTemplate = c.expandVars(serviceConfig.URLTemplate, warned)
+		for _, name := range SortedKeys(serviceConfig.Environments) {
+			path := "services." + service + ".environments." + name
+			envConfig := serviceConfig.Environments[name]
+			data := TemplateData{Env: name, Service: service}
+			if envConfig.Environment != "" {
+				data.Env = envConfig.Environment
+			}
+			envConfig, err := c.expandEnvironment(envConfig, data, path, warned)
+			if err != nil {
+				return err
+			}
+			serviceConfig.Environments[name] = envConfig
+		}
+		c.Services[service] = serviceConfig
+	}
+	return nil
+}
+
+// expandEnvironment expands every string in an environment, including those
+// in its log queries.
+func (c *Config) expandEnvironment(envConfig EnvironmentConfig, data TemplateData, path string, warned map[string]bool) (EnvironmentConfig, error) {
+	var err error
+	expandString := func(key string, v reflect.Value) {
+		if err != nil {
+			return
+		}
+		s := c.expandVars(v.String(), warned)
+		if key != "browser" && strings.Contains(s, "{{") {
+			s, err = renderValue(s, data)
+			if err != nil {
+				err = fmt.Errorf("%s.%s: %w", path, key, err)
+				return
+			}
+		}
+		v.SetString(s)
+	}
+	walkStrings(reflect.ValueOf(&envConfig).Elem(), "", expandString)
+	return envConfig, err
+}
+
+// walkStrings calls fn with every string field of the struct v, and every
+// string in its nested structs and maps.
+func walkStrings(v reflect.Value, key string, fn func(key string, v reflect.Value)) {
+	switch v.Kind() {
+	case reflect.String:
+		fn(key, v)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if k := yamlKey(v.Type().Field(i)); k != "" {
+				walkStrings(v.Field(i), k, fn)
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		// Maps may be shared with the environment they were inherited from,
+		// so the expanded values go into a copy
+		expanded := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			value := reflect.New(iter.Value().Type()).Elem()
+			value.Set(iter.Value())
+			walkStrings(value, key, fn)
+			expanded.SetMapIndex(iter.Key(), value)
+		}
+		v.Set(expanded)
+	}
+}
+
+// expandVars substitutes ${VAR} and ${VAR:-default}. A variable that is
+// unset and has no default expands to nothing, with a warning.
+func (c *Config) expandVars(s string, warned map[string]bool) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return envVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := envVarPattern.FindStringSubmatch(match)
+		name, hasDefault, fallback := groups[1], groups[2] != "", groups[3]
+		value, ok := os.LookupEnv(name)
+		if hasDefault && value == "" {
+			return fallback
+		}
+		if !ok && !warned[name] {
+			warned[name] = true
+			c.warnings = append(c.warnings, fmt.Sprintf("environment variable '%s' referenced in the configuration is not set", name))
+		}
+		return value
+	})
+}
+
+// renderValue renders a configuration value as a template.
+func renderValue(value string, data TemplateData) (string, error) {
+	tmpl, err := template.New("value").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
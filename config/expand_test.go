@@ -0,0 +1,89 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigExpansion(t *testing.T) {
+	t.Setenv("ACME_REGION", "europe-west1")
+	t.Setenv("ACME_EMPTY", "")
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, `
+account: ${ACME_ACCOUNT:-ops@example.com}
+defaults:
+  project_id: "acme-{{ .Env }}"
+  region: ${ACME_REGION}
+environments:
+  dev: {}
+  prod:
+    region: ${ACME_EMPTY:-us-central1}
+services:
+  logging:
+    environments:
+      dev:
+      prod-audit:
+        environment: prod
+        query:
+          labels:
+            service: "{{ .Service }}"
+  custom:
+    url_template: "https://example.com/{{.ProjectID}}"
+    environments:
+      sandbox:
+        browser: "firefox {{.URL}}"
+        cluster: ${ACME_UNSET}
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Account != "ops@example.com" {
+		t.Errorf("Account = %q, want the default", cfg.Account)
+	}
+	tests := []struct {
+		service, environment, project, region string
+	}{
+		{"logging", "dev", "acme-dev", "europe-west1"},
+		{"logging", "prod-audit", "acme-prod", "us-central1"},
+		{"custom", "sandbox", "acme-sandbox", "europe-west1"},
+	}
+	for _, tt := range tests {
+		env := cfg.Services[tt.service].Environments[tt.environment]
+		if env.ProjectID != tt.project || env.Region != tt.region {
+			t.Errorf("%s/%s = {%s %s}, want {%s %s}", tt.service, tt.environment, env.ProjectID, env.Region, tt.project, tt.region)
+		}
+	}
+	if got := cfg.Services["logging"].Environments["prod-audit"].Query.Labels["service"]; got != "logging" {
+		t.Errorf("query label = %q, want logging", got)
+	}
+	sandbox := cfg.Services["custom"].Environments["sandbox"]
+	if sandbox.Browser != "firefox {{.URL}}" {
+		t.Errorf("browser = %q, want it left for launch time", sandbox.Browser)
+	}
+	if cfg.Services["custom"].URLTemplate != "https://example.com/{{.ProjectID}}" {
+		t.Errorf("url_template = %q, want it left for launch time", cfg.Services["custom"].URLTemplate)
+	}
+	if sandbox.Cluster != "" {
+		t.Errorf("cluster = %q, want an unset variable to expand to nothing", sandbox.Cluster)
+	}
+	if warnings := cfg.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], "ACME_UNSET") {
+		t.Errorf("Warnings() = %v, want one about ACME_UNSET", warnings)
+	}
+}
+
+func TestLoadConfigExpansionError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, `
+services:
+  logging:
+    environments:
+      prod:
+        project_id: "acme-{{ .Team }}"
+`)
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "services.logging.environments.prod.project_id") {
+		t.Errorf("LoadConfig error = %v, want a template error naming the key", err)
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultGroupBy is the label environments are grouped by when GroupBy is
+// not set.
+const DefaultGroupBy = "tier"
+
+// GroupLabel returns the label the TUI groups environments by.
+func (c *Config) GroupLabel() string {
+	if c.GroupBy != "" {
+		return c.GroupBy
+	}
+	return DefaultGroupBy
+}
+
+// EnvironmentFilter selects environments by their tags and labels, ignoring
+// case. The zero value matches every environment.
+type EnvironmentFilter struct {
+	// Tags must all be among the environment's tags.
+	Tags []string
+	// Labels must all be set on the environment, to the given values.
+	Labels map[string]string
+}
+
+// ParseLabel splits a key=value label selector.
+func ParseLabel(s string) (string, string, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid label '%s': expected key=value", s)
+	}
+	return key, value, nil
+}
+
+// Empty reports whether the filter matches every environment.
+func (f EnvironmentFilter) Empty() bool {
+	return len(f.Tags) == 0 && len(f.Labels) == 0
+}
+
+// Matches reports whether an environment has every tag and label of the
+// filter.
+func (f EnvironmentFilter) Matches(env EnvironmentConfig) bool {
+	for _, tag := range f.Tags {
+		if !env.HasTag(tag) {
+			return false
+		}
+	}
+	for key, value := range f.Labels {
+		if !strings.EqualFold(env.Labels[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the filter for messages, e.g. "tag payments, tier=prod".
+func (f EnvironmentFilter) String() string {
+	var parts []string
+	for _, tag := range f.Tags {
+		parts = append(parts, "tag "+tag)
+	}
+	for _, key := range SortedKeys(f.Labels) {
+		parts = append(parts, key+"="+f.Labels[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FilterTargets returns the environment references whose environments
+// match the filter, in order. Bookmarks never match a non-empty filter.
+func (c *Config) FilterTargets(refs []TargetRef, f EnvironmentFilter) []TargetRef {
+	if f.Empty() {
+		return refs
+	}
+	var matched []TargetRef
+	for _, ref := range refs {
+		if ref.Bookmark == "" && f.Matches(c.Services[ref.Service].Environments[ref.Environment]) {
+			matched = append(matched, ref)
+		}
+	}
+	return matched
+}
+
+// HasTag reports whether the environment is tagged with tag, ignoring case.
+func (e EnvironmentConfig) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Production reports whether the environment is labelled tier: prod (or
+// production) or tagged prod (or production).
+func (e EnvironmentConfig) Production() bool {
+	for _, name := range []string{"prod", "production"} {
+		if strings.EqualFold(e.Labels["tier"], name) || e.HasTag(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagValues returns every tag used by an environment of any service type,
+// sorted.
+func (c *Config) TagValues() []string {
+	var values []string
+	for _, env := range c.allEnvironments() {
+		values = append(values, env.Tags...)
+	}
+	return uniqueSorted(values)
+}
+
+// LabelValues returns every value of the label key used by an environment
+// of any service type, sorted. With an empty key it returns every label as
+// key=value.
+func (c *Config) LabelValues(key string) []string {
+	var values []string
+	for _, env := range c.allEnvironments() {
+		for k, v := range env.Labels {
+			switch {
+			case key == "":
+				values = append(values, k+"="+v)
+			case k == key:
+				values = append(values, v)
+			}
+		}
+	}
+	return uniqueSorted(values)
+}
+
+// allEnvironments returns the environments of every service type.
+func (c *Config) allEnvironments() []EnvironmentConfig {
+	var envs []EnvironmentConfig
+	for _, service := range c.Services {
+		for _, env := range service.Environments {
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+// uniqueSorted sorts values and removes duplicates.
+func uniqueSorted(values []string) []string {
+	sort.Strings(values)
+	unique := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEnvironmentFilter(t *testing.T) {
+	cfg := &Config{
+		Services: map[string]ServiceTypeConfig{
+			"logging": {Environments: ServiceEnvironments{
+				"pay-prod":  {Tags: []string{"payments", "pci"}, Labels: map[string]string{"team": "checkout", "tier": "prod"}},
+				"pay-dev":   {Tags: []string{"payments"}, Labels: map[string]string{"team": "checkout", "tier": "dev"}},
+				"data-prod": {Tags: []string{"Production"}, Labels: map[string]string{"team": "data"}},
+				"scratch":   {},
+			}},
+		},
+	}
+	refs, err := cfg.ExpandTargets("logging", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		filter   EnvironmentFilter
+		expected string
+	}{
+		{name: "empty", expected: "[logging/data-prod logging/pay-dev logging/pay-prod logging/scratch]"},
+		{name: "tag", filter: EnvironmentFilter{Tags: []string{"payments"}}, expected: "[logging/pay-dev logging/pay-prod]"},
+		{name: "tags", filter: EnvironmentFilter{Tags: []string{"PAYMENTS", "pci"}}, expected: "[logging/pay-prod]"},
+		{name: "label", filter: EnvironmentFilter{Labels: map[string]string{"team": "checkout"}}, expected: "[logging/pay-dev logging/pay-prod]"},
+		{name: "tag and label", filter: EnvironmentFilter{Tags: []string{"payments"}, Labels: map[string]string{"tier": "Prod"}}, expected: "[logging/pay-prod]"},
+		{name: "no match", filter: EnvironmentFilter{Labels: map[string]string{"tier": "staging"}}, expected: "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(cfg.FilterTargets(refs, tt.filter)); got != tt.expected {
+				t.Errorf("FilterTargets(%s) = %s, want %s", tt.filter, got, tt.expected)
+			}
+		})
+	}
+
+	envs := cfg.Services["logging"].Environments
+	for name, want := range map[string]bool{"pay-prod": true, "pay-dev": false, "data-prod": true, "scratch": false} {
+		if got := envs[name].Production(); got != want {
+			t.Errorf("%s: Production() = %v, want %v", name, got, want)
+		}
+	}
+	if got := fmt.Sprint(cfg.TagValues()); got != "[Production payments pci]" {
+		t.Errorf("TagValues() = %s", got)
+	}
+	if got := fmt.Sprint(cfg.LabelValues("team")); got != "[checkout data]" {
+		t.Errorf("LabelValues(team) = %s, want [checkout data]", got)
+	}
+}
+
+func TestParseLabel(t *testing.T) {
+	tests := []struct {
+		input, key, value string
+		wantErr           bool
+	}{
+		{input: "team=checkout", key: "team", value: "checkout"},
+		{input: "tier=", key: "tier"},
+		{input: "a=b=c", key: "a", value: "b=c"},
+		{input: "team", wantErr: true},
+		{input: "=prod", wantErr: true},
+	}
+	for _, tt := range tests {
+		key, value, err := ParseLabel(tt.input)
+		if (err != nil) != tt.wantErr || key != tt.key || value != tt.value {
+			t.Errorf("ParseLabel(%q) = %q, %q, %v", tt.input, key, value, err)
+		}
+	}
+}
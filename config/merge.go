@@ -0,0 +1,329 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Files returns every configuration file that was loaded, highest
+// precedence first.
+func (c *Config) Files() []Candidate { return c.files }
+
+// Warnings returns problems that did not prevent loading, such as a remote
+// include that could not be refreshed and was read from the cache instead.
+func (c *Config) Warnings() []string { return c.warnings }
+
+// BookmarkSource returns the file that defined a bookmark, or "" if it is
+// not defined.
+func (c *Config) BookmarkSource(name string) string {
+	return c.sources[bookmarkSourceKey(name)]
+}
+
+func bookmarkSourceKey(name string) string {
+	return "bookmark:" + name
+}
+
+// EnvironmentSource returns the file that defined an environment of a
+// service type, or "" if it is not defined.
+func (c *Config) EnvironmentSource(service, environment string) string {
+	return c.sources[sourceKey(service, environment)]
+}
+
+func sourceKey(service, environment string) string {
+	return service + "/" + environment
+}
+
+// loadFile parses a single configuration file and everything it includes
+// into one layer. visiting guards against include cycles.
+func loadFile(candidate Candidate, visiting map[string]bool) (*Config, error) {
+	configFilePath := candidate.Path
+	absPath, err := filepath.Abs(configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving config file '%s': %w", configFilePath, err)
+	}
+	if visiting[absPath] {
+		return nil, fmt.Errorf("config file '%s' includes itself", configFilePath)
+	}
+	visiting[absPath] = true
+	defer delete(visiting, absPath)
+
+	// Read the entire content of the YAML file
+	yamlFile, err := os.ReadFile(configFilePath)
+	if err != nil {
+		// Return an error if the file cannot be read (e.g., not found, permissions)
+		return nil, fmt.Errorf("error reading config file '%s' (from %s): %w", configFilePath, candidate.Source, err)
+	}
+
+	// Parse the YAML content read from the file into the cfg struct,
+	// noting keys that do not correspond to a configuration field
+	var cfg Config
+	var document yaml.Node
+	if err := yaml.Unmarshal(yamlFile, &document); err != nil {
+		return nil, fmt.Errorf("error parsing config file '%s': %w", configFilePath, err)
+	}
+	cfg.positions = map[string]Position{}
+	if len(document.Content) > 0 {
+		root := document.Content[0]
+		cfg.unknown = checkKnownFields(root, reflect.TypeOf(cfg), candidate.Name(), "")
+		for _, e := range cfg.unknown {
+			cfg.warnings = append(cfg.warnings, e.Error())
+		}
+		if err := root.Decode(&cfg); err != nil {
+			// Return an error if the YAML content doesn't match the struct
+			return nil, fmt.Errorf("error parsing config file '%s': %w", configFilePath, err)
+		}
+		if candidate.Remote != "" {
+			cfg.dropBrowsers(candidate.Name())
+		}
+		recordPositions(root, candidate.Name(), "", cfg.positions)
+	}
+	cfg.files = []Candidate{candidate}
+	cfg.sources = map[string]string{}
+	for environment := range cfg.Environments {
+		cfg.sources[sourceKey("", environment)] = candidate.Name()
+	}
+	for name := range cfg.Bookmarks {
+		cfg.sources[bookmarkSourceKey(name)] = candidate.Name()
+	}
+	for service, serviceConfig := range cfg.Services {
+		for environment := range serviceConfig.Environments {
+			cfg.sources[sourceKey(service, environment)] = candidate.Name()
+		}
+	}
+
+	includes, warnings, err := expandIncludes(cfg.Include, candidate)
+	if err != nil {
+		return nil, fmt.Errorf("error in includes of config file '%s': %w", configFilePath, err)
+	}
+	cfg.warnings = append(cfg.warnings, warnings...)
+	// Later includes take precedence over earlier ones
+	for i := len(includes) - 1; i >= 0; i-- {
+		included, err := loadFile(includes[i], visiting)
+		if err != nil {
+			return nil, err
+		}
+		cfg.merge(included)
+	}
+	return &cfg, nil
+}
+
+// dropBrowsers clears every browser setting of a layer fetched from a
+// remote include. A browser can be a command template run on every launch,
+// so only local files may choose it.
+func (c *Config) dropBrowsers(from string) {
+	ignore := func(path, browser string) {
+		c.warnings = append(c.warnings, fmt.Sprintf("ignoring %s '%s' from remote include '%s': only local configuration files can set the browser", path, browser, from))
+	}
+	if c.Browser != "" {
+		ignore("browser", c.Browser)
+		c.Browser = ""
+	}
+	if c.Defaults.Browser != "" {
+		ignore("defaults.browser", c.Defaults.Browser)
+		c.Defaults.Browser = ""
+	}
+	for _, name := range SortedKeys(c.Environments) {
+		if env := c.Environments[name]; env.Browser != "" {
+			ignore("environments."+name+".browser", env.Browser)
+			env.Browser = ""
+			c.Environments[name] = env
+		}
+	}
+	for _, service := range SortedKeys(c.Services) {
+		environments := c.Services[service].Environments
+		for _, name := range SortedKeys(environments) {
+			if env := environments[name]; env.Browser != "" {
+				ignore("services."+service+".environments."+name+".browser", env.Browser)
+				env.Browser = ""
+				environments[name] = env
+			}
+		}
+	}
+}
+
+// expandIncludes resolves the include entries of the file at from into the
+// files to load. Local paths are resolved relative to the including file, or
+// to its URL when it was fetched over HTTP(S), remote includes are fetched
+// into the cache (see fetchRemote), and directories expand into the YAML
+// files they contain, in lexical order. Warnings about remote includes
+// served from a stale cache are returned alongside.
+func expandIncludes(includes []string, candidate Candidate) ([]Candidate, []string, error) {
+	var candidates []Candidate
+	var warnings []string
+	from := candidate.Name()
+	for _, include := range includes {
+		if isHTTP(candidate.Remote) && !isRemote(include) {
+			resolved, err := resolveURL(candidate.Remote, include)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot include '%s' from '%s': %w", include, from, err)
+			}
+			include = resolved
+		}
+		path := include
+		remote := ""
+		if isRemote(include) {
+			var warning string
+			var err error
+			path, warning, err = fetchRemote(include)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot include '%s': %w", include, err)
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			remote = include
+		} else {
+			if strings.HasPrefix(path, "~/") {
+				if home, err := os.UserHomeDir(); err == nil {
+					path = filepath.Join(home, path[2:])
+				}
+			}
+			if !filepath.IsAbs(path) {
+				// A file from a git include is resolved within the clone,
+				// and is as remote as the file including it
+				path = filepath.Join(filepath.Dir(candidate.Path), path)
+				if candidate.Remote != "" {
+					remote = candidate.Remote + " > " + filepath.ToSlash(include)
+				}
+			}
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot include '%s': %w", include, err)
+		}
+		if !info.IsDir() {
+			candidates = append(candidates, Candidate{Path: path, Source: "included by " + from, Remote: remote})
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot include directory '%s': %w", include, err)
+		}
+		var files []string
+		for _, e := range entries {
+			ext := filepath.Ext(e.Name())
+			if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, e.Name())
+			}
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			c := Candidate{Path: filepath.Join(path, f), Source: "included by " + from}
+			if remote != "" {
+				c.Remote = strings.TrimSuffix(remote, "/") + "/" + f
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, warnings, nil
+}
+
+// merge fills in c from a lower-precedence configuration: top-level settings
+// (including the list of favourites as a whole) and service type settings are taken from lower only when unset in c,
+// service type aliases are combined,
+// environments are added, and environments c already defines take the fields
+// they leave unset from lower.
+func (c *Config) merge(lower *Config) {
+	if c.Browser == "" {
+		c.Browser = lower.Browser
+	}
+	if c.Account == "" {
+		c.Account = lower.Account
+	}
+	c.Defaults = lower.Defaults.overlay(c.Defaults)
+	if len(c.Favourites) == 0 {
+		c.Favourites = lower.Favourites
+	}
+	// Protection only ever adds up, so no layer can lift another's
+	for _, pattern := range lower.Protected {
+		if !slices.Contains(c.Protected, pattern) {
+			c.Protected = append(c.Protected, pattern)
+		}
+	}
+	if c.GroupBy == "" {
+		c.GroupBy = lower.GroupBy
+	}
+	if c.Services == nil && lower.Services != nil {
+		c.Services = map[string]ServiceTypeConfig{}
+	}
+	if c.sources == nil {
+		c.sources = map[string]string{}
+	}
+	for environment, envConfig := range lower.Environments {
+		if higher, exists := c.Environments[environment]; exists {
+			c.Environments[environment] = envConfig.overlay(higher)
+			continue
+		}
+		if c.Environments == nil {
+			c.Environments = map[string]EnvironmentConfig{}
+		}
+		c.Environments[environment] = envConfig
+		c.sources[sourceKey("", environment)] = lower.sources[sourceKey("", environment)]
+	}
+	for name, workspace := range lower.Workspaces {
+		if _, exists := c.Workspaces[name]; exists {
+			continue
+		}
+		if c.Workspaces == nil {
+			c.Workspaces = map[string][]string{}
+		}
+		c.Workspaces[name] = workspace
+	}
+	for name, bookmark := range lower.Bookmarks {
+		if _, exists := c.Bookmarks[name]; exists {
+			continue
+		}
+		if c.Bookmarks == nil {
+			c.Bookmarks = map[string]Bookmark{}
+		}
+		c.Bookmarks[name] = bookmark
+		c.sources[bookmarkSourceKey(name)] = lower.sources[bookmarkSourceKey(name)]
+	}
+	for service, lowerService := range lower.Services {
+		serviceConfig, ok := c.Services[service]
+		if !ok {
+			serviceConfig = ServiceTypeConfig{}
+		}
+		if serviceConfig.URLTemplate == "" {
+			serviceConfig.URLTemplate = lowerService.URLTemplate
+		}
+		if serviceConfig.ContextParam == "" {
+			serviceConfig.ContextParam = lowerService.ContextParam
+		}
+		for _, alias := range lowerService.Aliases {
+			if !slices.Contains(serviceConfig.Aliases, alias) {
+				serviceConfig.Aliases = append(serviceConfig.Aliases, alias)
+			}
+		}
+		for environment, envConfig := range lowerService.Environments {
+			if higher, exists := serviceConfig.Environments[environment]; exists {
+				serviceConfig.Environments[environment] = envConfig.overlay(higher)
+				continue
+			}
+			if serviceConfig.Environments == nil {
+				serviceConfig.Environments = ServiceEnvironments{}
+			}
+			serviceConfig.Environments[environment] = envConfig
+			c.sources[sourceKey(service, environment)] = lower.sources[sourceKey(service, environment)]
+		}
+		c.Services[service] = serviceConfig
+	}
+	if c.positions == nil {
+		c.positions = map[string]Position{}
+	}
+	for path, pos := range lower.positions {
+		if _, ok := c.positions[path]; !ok {
+			c.positions[path] = pos
+		}
+	}
+	c.files = append(c.files, lower.files...)
+	c.warnings = append(c.warnings, lower.warnings...)
+	c.unknown = append(c.unknown, lower.unknown...)
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigLayers(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	repo := filepath.Join(root, "repo")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "xdg"))
+	t.Setenv(EnvConfigPath, "")
+	writeFile(t, filepath.Join(repo, ".git", "HEAD"), "")
+	chdir(t, repo)
+
+	// The team catalog is pulled in by the user's file via a directory include.
+	writeFile(t, filepath.Join(home, "team", "10-base.yaml"), `
+account: team@example.com
+services:
+  logging:
+    environments:
+      prod:
+        project_id: team-prod
+      staging:
+        project_id: team-staging
+  spanner:
+    environments:
+      prod:
+        project_id: team-spanner
+`)
+	writeFile(t, filepath.Join(home, "team", "20-override.yaml"), `
+services:
+  spanner:
+    environments:
+      prod:
+        project_id: team-spanner-v2
+`)
+	writeFile(t, filepath.Join(home, FileName), `
+include:
+  - team
+services:
+  logging:
+    environments:
+      staging:
+        project_id: my-staging
+      sandbox:
+        project_id: my-sandbox
+`)
+	writeFile(t, filepath.Join(repo, FileName), `
+services:
+  logging:
+    environments:
+      prod:
+        project_id: repo-prod
+`)
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	expected := map[string]struct{ project, source string }{
+		"logging/prod":    {"repo-prod", filepath.Join(repo, FileName)},
+		"logging/staging": {"my-staging", filepath.Join(home, FileName)},
+		"logging/sandbox": {"my-sandbox", filepath.Join(home, FileName)},
+		"spanner/prod":    {"team-spanner-v2", filepath.Join(home, "team", "20-override.yaml")},
+	}
+	for key, want := range expected {
+		service, environment, _ := strings.Cut(key, "/")
+		got := cfg.Services[service].Environments[environment].ProjectID
+		if got != want.project {
+			t.Errorf("%s project_id = %q, want %q", key, got, want.project)
+		}
+		if src := cfg.EnvironmentSource(service, environment); src != want.source {
+			t.Errorf("%s source = %q, want %q", key, src, want.source)
+		}
+	}
+	if cfg.Account != "team@example.com" {
+		t.Errorf("Account = %q, want it inherited from the team catalog", cfg.Account)
+	}
+	files := cfg.Files()
+	wantFiles := []string{
+		filepath.Join(repo, FileName),
+		filepath.Join(home, FileName),
+		filepath.Join(home, "team", "20-override.yaml"),
+		filepath.Join(home, "team", "10-base.yaml"),
+	}
+	if len(files) != len(wantFiles) {
+		t.Fatalf("Files() = %v, want %v", files, wantFiles)
+	}
+	for i, f := range files {
+		if f.Path != wantFiles[i] {
+			t.Errorf("Files()[%d] = %s, want %s", i, f.Path, wantFiles[i])
+		}
+	}
+}
+
+func TestLoadConfigIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.yaml")
+	writeFile(t, missing, "include: [nope.yaml]\n")
+	if _, err := LoadConfig(missing); err == nil {
+		t.Error("LoadConfig() expected error for missing include, got nil")
+	}
+
+	cycle := filepath.Join(dir, "a.yaml")
+	writeFile(t, cycle, "include: [b.yaml]\n")
+	writeFile(t, filepath.Join(dir, "b.yaml"), "include: [a.yaml]\n")
+	if _, err := LoadConfig(cycle); err == nil {
+		t.Error("LoadConfig() expected error for include cycle, got nil")
+	}
+}
+
+func TestLoadConfigMergesEnvironmentFields(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "team.yaml"), `
+environments:
+  prod:
+    project_id: team-prod
+    labels: {team: payments}
+services:
+  cloudrun:
+    environments:
+      prod:
+        project_id: team-prod
+        region: us-central1
+        service: checkout
+`)
+	path := filepath.Join(dir, FileName)
+	writeFile(t, path, `
+include: [team.yaml]
+environments:
+  prod:
+    labels: {tier: prod}
+services:
+  cloudrun:
+    environments:
+      prod:
+        region: europe-west1
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	got := cfg.Services["cloudrun"].Environments["prod"]
+	if got.ProjectID != "team-prod" || got.Region != "europe-west1" || got.Service != "checkout" {
+		t.Errorf("cloudrun/prod = %+v, want region overridden and project_id and service kept", got)
+	}
+	if labels := cfg.Environments["prod"].Labels; labels["team"] != "payments" || labels["tier"] != "prod" || cfg.Environments["prod"].ProjectID != "team-prod" {
+		t.Errorf("prod = %+v, want labels combined and project_id kept", cfg.Environments["prod"])
+	}
+}
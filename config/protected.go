@@ -0,0 +1,26 @@
+package config
+
+import "path"
+
+// IsProtected reports whether an environment of a service type, or a
+// top-level environment when service is empty, is protected: marked
+// protected: true, or matched by one of the protected patterns by name or
+// as "service/environment".
+func (c *Config) IsProtected(service, environment string) bool {
+	env, ok := c.Environments[environment]
+	if service != "" {
+		env, ok = c.Services[service].Environments[environment]
+	}
+	if ok && env.Protected {
+		return true
+	}
+	for _, pattern := range c.Protected {
+		if matched, _ := path.Match(pattern, environment); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, service+"/"+environment); service != "" && matched {
+			return true
+		}
+	}
+	return false
+}
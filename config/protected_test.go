@@ -0,0 +1,50 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsProtected(t *testing.T) {
+	cfg := &Config{
+		Environments: map[string]EnvironmentConfig{"shared-prod": {Protected: true}, "shared-dev": {}},
+		Services: map[string]ServiceTypeConfig{
+			"logging": {Environments: ServiceEnvironments{"prod": {Protected: true}, "dev": {}, "apps-live": {}}},
+			"gke":     {Environments: ServiceEnvironments{"apps-live": {}, "dev": {}}},
+		},
+		Protected: []string{"*-live", "gke/dev"},
+	}
+	tests := []struct {
+		service, environment string
+		expected             bool
+	}{
+		{"logging", "prod", true},
+		{"logging", "dev", false},
+		{"logging", "apps-live", true},
+		{"gke", "apps-live", true},
+		{"gke", "dev", true},
+		{"", "shared-prod", true},
+		{"", "shared-dev", false},
+		{"", "dev", false},
+	}
+	for _, tt := range tests {
+		if got := cfg.IsProtected(tt.service, tt.environment); got != tt.expected {
+			t.Errorf("IsProtected(%q, %q) = %v, want %v", tt.service, tt.environment, got, tt.expected)
+		}
+	}
+}
+
+func TestProtectedMerge(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "team.yaml"), "protected: [\"*-prod\"]\nservices: {}\n")
+	path := filepath.Join(dir, FileName)
+	writeFile(t, path, "include: [team.yaml]\nprotected: [\"*-live\"]\nservices:\n  logging:\n    environments:\n      apps-prod:\n        project_id: apps-prod\n")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	// A layer cannot lift the protection another layer adds
+	if !cfg.IsProtected("logging", "apps-prod") || !cfg.IsProtected("logging", "x-live") {
+		t.Errorf("Protected = %v, want the patterns of both files", cfg.Protected)
+	}
+}
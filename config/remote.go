@@ -0,0 +1,248 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitPrefix marks an include as a git repository, in the form
+// git::<repository>[//<path in repository>][?ref=<branch or tag>].
+const gitPrefix = "git::"
+
+// httpClient fetches remote includes; the timeout keeps an unreachable
+// server from blocking every launch.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// CacheTTL is how long a cached remote include is used as is before it is
+// revalidated; zero revalidates it on every load.
+var CacheTTL time.Duration
+
+// isRemote reports whether an include entry refers to an HTTP(S) URL or a
+// git repository rather than a local path.
+func isRemote(include string) bool {
+	return isHTTP(include) || strings.HasPrefix(include, gitPrefix)
+}
+
+// isHTTP reports whether an include entry is an HTTP(S) URL.
+func isHTTP(include string) bool {
+	return strings.HasPrefix(include, "https://") || strings.HasPrefix(include, "http://")
+}
+
+// resolveURL resolves a relative include of a file fetched from base against
+// its URL. Absolute paths and paths in the home directory cannot be reached
+// from a remote file.
+func resolveURL(base, include string) (string, error) {
+	if filepath.IsAbs(include) || strings.HasPrefix(include, "~/") {
+		return "", fmt.Errorf("a file fetched over HTTP can only include URLs and paths relative to its own URL")
+	}
+	baseURL, err := neturl.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := neturl.Parse(filepath.ToSlash(include))
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(ref).String(), nil
+}
+
+// fresh reports whether the cached copy at path was brought up to date
+// within CacheTTL.
+func fresh(path string) bool {
+	if CacheTTL <= 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) < CacheTTL
+}
+
+// touch marks the cached copy at path as brought up to date now.
+func touch(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// CacheDir returns the directory remote includes are cached in:
+// $XDG_CACHE_HOME/gcp-launch, or the platform's user cache directory.
+func CacheDir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "gcp-launch"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, "gcp-launch"), nil
+}
+
+// fetchRemote brings the cached copy of a remote include up to date and
+// returns its local path. If the remote cannot be reached but a cached copy
+// exists, the cached copy is used and a warning is returned.
+func fetchRemote(include string) (string, string, error) {
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(include))
+	key := hex.EncodeToString(sum[:])[:16]
+	if strings.HasPrefix(include, gitPrefix) {
+		return fetchGit(strings.TrimPrefix(include, gitPrefix), filepath.Join(cacheDir, "git", key))
+	}
+	return fetchHTTP(include, filepath.Join(cacheDir, "http", key+".yaml"))
+}
+
+// fetchHTTP downloads url to cachePath, revalidating an existing copy with
+// its ETag.
+func fetchHTTP(url, cachePath string) (string, string, error) {
+	if fresh(cachePath) {
+		return cachePath, "", nil
+	}
+	etagPath := cachePath + ".etag"
+	_, statErr := os.Stat(cachePath)
+	cached := statErr == nil
+
+	stale := func(reason error) (string, string, error) {
+		if !cached {
+			return "", "", reason
+		}
+		return cachePath, fmt.Sprintf("using cached copy of '%s': %v", url, reason), nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", "", err
+	}
+	if cached {
+		if etag, err := os.ReadFile(etagPath); err == nil && len(etag) > 0 {
+			req.Header.Set("If-None-Match", string(etag))
+		}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return stale(err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		touch(cachePath)
+		return cachePath, "", nil
+	case resp.StatusCode != http.StatusOK:
+		return stale(fmt.Errorf("unexpected HTTP status %s", resp.Status))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return stale(err)
+	}
+	if err := writeFileAtomic(cachePath, body); err != nil {
+		return "", "", err
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		_ = writeFileAtomic(etagPath, []byte(etag))
+	} else {
+		_ = os.Remove(etagPath)
+	}
+	return cachePath, "", nil
+}
+
+// fetchGit clones or updates a shallow copy of a git repository in cloneDir
+// and returns the path of the requested file or directory inside it. spec is
+// <repository>[//<path>][?ref=<ref>].
+func fetchGit(spec, cloneDir string) (string, string, error) {
+	repo, ref, _ := strings.Cut(spec, "?ref=")
+	subPath := ""
+	// The repository URL itself may contain "://", so look for the
+	// subdirectory separator after it.
+	searchFrom := 0
+	if i := strings.Index(repo, "://"); i >= 0 {
+		searchFrom = i + 3
+	}
+	if i := strings.Index(repo[searchFrom:], "//"); i >= 0 {
+		subPath = repo[searchFrom+i+2:]
+		repo = repo[:searchFrom+i]
+	}
+	if repo == "" {
+		return "", "", fmt.Errorf("missing repository in git include")
+	}
+	target := filepath.Join(cloneDir, filepath.FromSlash(subPath))
+
+	if _, err := os.Stat(filepath.Join(cloneDir, ".git")); err == nil {
+		if fresh(cloneDir) {
+			return target, "", nil
+		}
+		fetchArgs := []string{"-C", cloneDir, "fetch", "--depth", "1", "origin"}
+		if ref != "" {
+			fetchArgs = append(fetchArgs, ref)
+		}
+		if err := runGit(fetchArgs...); err != nil {
+			return target, fmt.Sprintf("using cached copy of '%s': %v", repo, err), nil
+		}
+		if err := runGit("-C", cloneDir, "checkout", "--quiet", "--force", "FETCH_HEAD"); err != nil {
+			return target, fmt.Sprintf("using cached copy of '%s': %v", repo, err), nil
+		}
+		touch(cloneDir)
+		return target, "", nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cloneDir), 0755); err != nil {
+		return "", "", err
+	}
+	cloneArgs := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		cloneArgs = append(cloneArgs, "--branch", ref)
+	}
+	if err := runGit(append(cloneArgs, repo, cloneDir)...); err != nil {
+		_ = os.RemoveAll(cloneDir)
+		return "", "", err
+	}
+	touch(cloneDir)
+	return target, "", nil
+}
+
+// runGit runs git non-interactively, returning its stderr on failure.
+func runGit(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to path via a temporary file so a failed
+// download never leaves a truncated cache entry behind. A symlink at path is
+// followed so that its target is replaced rather than the link.
+func writeFileAtomic(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gcp-launch-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
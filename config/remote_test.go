@@ -0,0 +1,221 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const remoteCatalog = `
+services:
+  logging:
+    environments:
+      shared:
+        project_id: shared-project
+`
+
+func TestRemoteIncludeHTTP(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var fetches, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(remoteCatalog))
+	}))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "include: ["+server.URL+"/catalog.yaml]\n")
+
+	load := func() *Config {
+		t.Helper()
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if got := cfg.Services["logging"].Environments["shared"].ProjectID; got != "shared-project" {
+			t.Fatalf("shared project_id = %q, want shared-project", got)
+		}
+		return cfg
+	}
+
+	cfg := load()
+	if src := cfg.EnvironmentSource("logging", "shared"); src != server.URL+"/catalog.yaml" {
+		t.Errorf("EnvironmentSource() = %q, want the include URL", src)
+	}
+	load()
+	if fetches.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("fetches = %d, not modified = %d; want the second load revalidated with the ETag", fetches.Load(), notModified.Load())
+	}
+
+	// Offline: the cached copy is used, with a warning.
+	server.Close()
+	cfg = load()
+	if len(cfg.Warnings()) != 1 {
+		t.Errorf("Warnings() = %v, want one stale cache warning", cfg.Warnings())
+	}
+
+	// Offline without a cached copy is an error.
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig() expected error for unreachable include without cache, got nil")
+	}
+}
+
+func TestRemoteIncludeGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, "gcp-launch", "catalog.yaml"), remoteCatalog)
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch", "main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "catalog"},
+	} {
+		if err := runGit(append([]string{"-C", repo}, args...)...); err != nil {
+			t.Fatalf("setting up repository: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "include: [\"git::file://"+repo+"//gcp-launch?ref=main\"]\n")
+	for i := 0; i < 2; i++ { // clone, then update
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if got := cfg.Services["logging"].Environments["shared"].ProjectID; got != "shared-project" {
+			t.Errorf("shared project_id = %q, want shared-project", got)
+		}
+		if len(cfg.Warnings()) != 0 {
+			t.Errorf("Warnings() = %v, want none", cfg.Warnings())
+		}
+	}
+}
+
+func TestRemoteIncludeRelative(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	files := map[string]string{
+		"/team/base.yaml":     "include: [services.yaml, ../shared/extra.yaml]\n",
+		"/team/services.yaml": remoteCatalog,
+		"/shared/extra.yaml":  "environments:\n  extra:\n    project_id: extra-project\n",
+		"/team/absolute.yaml": "include: [/etc/gcp-launch.yaml]\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "include: ["+server.URL+"/team/base.yaml]\n")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if src := cfg.EnvironmentSource("logging", "shared"); src != server.URL+"/team/services.yaml" {
+		t.Errorf("EnvironmentSource(logging, shared) = %q, want the URL relative to the including file", src)
+	}
+	if got := cfg.Environments["extra"].ProjectID; got != "extra-project" {
+		t.Errorf("extra project_id = %q, want extra-project", got)
+	}
+
+	writeFile(t, path, "include: ["+server.URL+"/team/absolute.yaml]\n")
+	_, err = LoadConfig(path)
+	if want := "cannot include '/etc/gcp-launch.yaml' from '" + server.URL + "/team/absolute.yaml'"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("LoadConfig() error = %v, want it to contain %q", err, want)
+	}
+}
+
+func TestRemoteIncludeCacheTTL(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	defer func(ttl time.Duration) { CacheTTL = ttl }(CacheTTL)
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write([]byte(remoteCatalog))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "include: ["+server.URL+"/catalog.yaml]\n")
+	CacheTTL = time.Hour
+	for i := 0; i < 2; i++ {
+		if _, err := LoadConfig(path); err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("fetches = %d, want the second load served from the cache", fetches.Load())
+	}
+	CacheTTL = 0
+	if _, err := LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("fetches = %d, want the load without a TTL revalidated", fetches.Load())
+	}
+}
+
+func TestRemoteIncludeBrowserIgnored(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	const payload = `sh -c 'touch /tmp/pwned' {{.URL}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`
+browser: "` + payload + `"
+defaults:
+  browser: "` + payload + `"
+environments:
+  shared:
+    project_id: shared-project
+    browser: "` + payload + `"
+services:
+  logging:
+    environments:
+      shared:
+        browser: "` + payload + `"
+`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "include: ["+server.URL+"/team.yaml]\n")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	env := cfg.Services["logging"].Environments["shared"]
+	if cfg.Browser != "" || cfg.Defaults.Browser != "" || env.Browser != "" {
+		t.Errorf("browser = %q, defaults = %q, logging/shared = %q; want the remote browser ignored", cfg.Browser, cfg.Defaults.Browser, env.Browser)
+	}
+	if env.ProjectID != "shared-project" {
+		t.Errorf("logging/shared project_id = %q, want the rest of the remote file kept", env.ProjectID)
+	}
+	if len(cfg.Warnings()) != 4 {
+		t.Errorf("Warnings() = %v, want one per ignored browser", cfg.Warnings())
+	}
+
+	// A local file can still choose the browser
+	writeFile(t, path, "browser: firefox\ninclude: ["+server.URL+"/team.yaml]\n")
+	if cfg, err = LoadConfig(path); err != nil || cfg.Browser != "firefox" {
+		t.Errorf("LoadConfig() browser = %q, %v; want the local firefox", cfg.Browser, err)
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResolveService returns the service type that name refers to: a service
+// type, one of its aliases, or a unique prefix of either.
+func (c *Config) ResolveService(name string) (string, error) {
+	names := map[string]string{}
+	for key, serviceConfig := range c.Services {
+		for _, alias := range serviceConfig.Aliases {
+			names[alias] = key
+		}
+	}
+	for key := range c.Services {
+		names[key] = key
+	}
+	key, matches := lookupName(name, names)
+	switch {
+	case key != "":
+		return key, nil
+	case len(matches) > 1:
+		return "", fmt.Errorf("service type '%s' is ambiguous: it matches %s", name, strings.Join(matches, ", "))
+	default:
+		return "", fmt.Errorf("service type '%s' not found in configuration%s", name, suggest(name, names))
+	}
+}
+
+// ResolveEnvironment returns the environment of a service type that name
+// refers to: an environment, one of its aliases, or a unique prefix of
+// either.
+func (c *Config) ResolveEnvironment(service, name string) (string, error) {
+	names := map[string]string{}
+	environments := c.Services[service].Environments
+	for key, envConfig := range environments {
+		for _, alias := range envConfig.Aliases {
+			names[alias] = key
+		}
+	}
+	for key := range environments {
+		names[key] = key
+	}
+	key, matches := lookupName(name, names)
+	switch {
+	case key != "":
+		return key, nil
+	case len(matches) > 1:
+		return "", fmt.Errorf("environment '%s' is ambiguous for service type '%s': it matches %s", name, service, strings.Join(matches, ", "))
+	default:
+		return "", fmt.Errorf("environment '%s' not found for service type '%s' in configuration%s", name, service, suggest(name, names))
+	}
+}
+
+// ResolveBookmark returns the bookmark that name refers to: a bookmark or
+// a unique prefix of one.
+func (c *Config) ResolveBookmark(name string) (string, error) {
+	names := map[string]string{}
+	for key := range c.Bookmarks {
+		names[key] = key
+	}
+	key, matches := lookupName(name, names)
+	switch {
+	case key != "":
+		return key, nil
+	case len(matches) > 1:
+		return "", fmt.Errorf("bookmark '%s' is ambiguous: it matches %s", name, strings.Join(matches, ", "))
+	default:
+		return "", fmt.Errorf("bookmark '%s' not found in configuration%s", name, suggest(name, names))
+	}
+}
+
+// ResolveTarget resolves a "service/environment" reference, as used in
+// favourites, to the service type and environment it refers to.
+func (c *Config) ResolveTarget(ref string) (string, string, error) {
+	service, environment, ok := strings.Cut(ref, "/")
+	if !ok || service == "" || environment == "" {
+		return "", "", fmt.Errorf("invalid reference '%s': expected service/environment", ref)
+	}
+	service, err := c.ResolveService(service)
+	if err != nil {
+		return "", "", err
+	}
+	environment, err = c.ResolveEnvironment(service, environment)
+	if err != nil {
+		return "", "", err
+	}
+	return service, environment, nil
+}
+
+// ResolveWorkspace returns the name of the workspace that name refers to:
+// the workspace itself or the only one it is a prefix of.
+func (c *Config) ResolveWorkspace(name string) (string, error) {
+	names := map[string]string{}
+	for key := range c.Workspaces {
+		names[key] = key
+	}
+	key, matches := lookupName(name, names)
+	switch {
+	case key != "":
+		return key, nil
+	case len(matches) > 1:
+		return "", fmt.Errorf("workspace '%s' is ambiguous: it matches %s", name, strings.Join(matches, ", "))
+	default:
+		return "", fmt.Errorf("workspace '%s' not found in configuration%s", name, suggest(name, names))
+	}
+}
+
+// lookupName finds the key that name refers to in names, which maps keys and
+// aliases to keys. An exact match wins; otherwise name must be the prefix of
+// the names of exactly one key. If it is the prefix of several, they are
+// returned sorted instead.
+func lookupName(name string, names map[string]string) (string, []string) {
+	if key, ok := names[name]; ok {
+		return key, nil
+	}
+	seen := map[string]bool{}
+	var matches []string
+	for n, key := range names {
+		if strings.HasPrefix(n, name) && !seen[key] {
+			seen[key] = true
+			matches = append(matches, key)
+		}
+	}
+	sort.Strings(matches)
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return "", matches
+}
+
+// suggest returns a "did you mean" hint for a name that was not found.
+func suggest(name string, names map[string]string) string {
+	candidates := make([]string, 0, len(names))
+	for n := range names {
+		candidates = append(candidates, n)
+	}
+	sort.Strings(candidates)
+	if suggestion := closest(name, candidates); suggestion != "" {
+		return fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+	}
+	return ""
+}
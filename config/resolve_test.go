@@ -0,0 +1,114 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	cfg := &Config{
+		Services: map[string]ServiceTypeConfig{
+			"logging":  {Aliases: []string{"logs"}, Environments: ServiceEnvironments{"myproject-prod": {Aliases: []string{"prod", "p"}}, "myproject-dev": {}, "staging": {}}},
+			"cloudrun": {Environments: ServiceEnvironments{"prod": {}}},
+			"cloudsql": {},
+		},
+	}
+	tests := []struct {
+		service, environment string
+		expected             string
+		expectedError        string
+	}{
+		{service: "logging", environment: "myproject-prod", expected: "logging/myproject-prod"},
+		{service: "logs", environment: "prod", expected: "logging/myproject-prod"},
+		{service: "log", environment: "p", expected: "logging/myproject-prod"},
+		{service: "logging", environment: "myproject-d", expected: "logging/myproject-dev"},
+		{service: "logging", environment: "st", expected: "logging/staging"},
+		{service: "cloudr", environment: "prod", expected: "cloudrun/prod"},
+		{service: "cloud", expectedError: "service type 'cloud' is ambiguous: it matches cloudrun, cloudsql"},
+		{service: "loging", expectedError: "service type 'loging' not found in configuration (did you mean 'logging'?)"},
+		{service: "spanner", expectedError: "service type 'spanner' not found in configuration"},
+		{service: "logging", environment: "myproject", expectedError: "environment 'myproject' is ambiguous for service type 'logging': it matches myproject-dev, myproject-prod"},
+		{service: "logging", environment: "stagin", expected: "logging/staging"},
+		{service: "logging", environment: "staing", expectedError: "environment 'staing' not found for service type 'logging' in configuration (did you mean 'staging'?)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service+"/"+tt.environment, func(t *testing.T) {
+			service, err := cfg.ResolveService(tt.service)
+			environment := ""
+			if err == nil {
+				environment, err = cfg.ResolveEnvironment(service, tt.environment)
+			}
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("error = %v, want %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := service + "/" + environment; got != tt.expected {
+				t.Errorf("resolved %s/%s to %s, want %s", tt.service, tt.environment, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateAliases(t *testing.T) {
+	cfg := &Config{
+		Services: map[string]ServiceTypeConfig{
+			"logging": {Aliases: []string{"logs", "spanner"}, Environments: ServiceEnvironments{
+				"prod":    {ProjectID: "acme-prod", Aliases: []string{"p"}},
+				"preprod": {ProjectID: "acme-preprod", Aliases: []string{"p"}},
+			}},
+			"spanner": {},
+		},
+	}
+	errs := cfg.Validate(func(string) ([]string, bool) { return nil, true })
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Path+": "+e.Message)
+	}
+	want := []string{
+		"services.logging.aliases: alias 'spanner' is already used by 'spanner'",
+		"services.logging.environments.prod.aliases: alias 'p' is already used by 'preprod'",
+	}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Validate() = %q, want %q", messages, want)
+	}
+}
+
+func TestResolveAliasesFromFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "team.yaml"), `
+services:
+  logging:
+    aliases: [l]
+`)
+	path := filepath.Join(dir, FileName)
+	writeFile(t, path, `
+include: [team.yaml]
+services:
+  logging:
+    aliases: [logs]
+    environments:
+      myproject-prod:
+        project_id: myproject-prod
+        aliases: [prod]
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	for _, alias := range []string{"logs", "l"} {
+		service, err := cfg.ResolveService(alias)
+		if err != nil || service != "logging" {
+			t.Errorf("ResolveService(%s) = %q, %v; want logging", alias, service, err)
+			continue
+		}
+		if environment, err := cfg.ResolveEnvironment(service, "prod"); err != nil || environment != "myproject-prod" {
+			t.Errorf("ResolveEnvironment(%s, prod) = %q, %v; want myproject-prod", service, environment, err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package config
+
+import _ "embed"
+
+// Schema is a JSON Schema describing the configuration file, for editors
+// that offer completion and validation of YAML against a schema.
+//
+//go:embed schema.json
+var Schema []byte
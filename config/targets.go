@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// TargetRef names something to open: an environment of a service type, or
+// a bookmark.
+type TargetRef struct {
+	Service     string
+	Environment string
+	Bookmark    string
+}
+
+// String returns the reference in the form used in favourites and
+// workspaces.
+func (r TargetRef) String() string {
+	if r.Bookmark != "" {
+		return "bookmark:" + r.Bookmark
+	}
+	return r.Service + "/" + r.Environment
+}
+
+// ExpandTargets resolves service type and environment arguments that may
+// each be a comma-separated list, with environments also given as glob
+// patterns such as *-prod, into every combination they name. Names resolve
+// as in ResolveService and ResolveEnvironment; a pattern must match at least
+// one environment.
+func (c *Config) ExpandTargets(services, environments string) ([]TargetRef, error) {
+	var refs []TargetRef
+	seen := map[TargetRef]bool{}
+	for _, serviceArg := range strings.Split(services, ",") {
+		service, err := c.ResolveService(strings.TrimSpace(serviceArg))
+		if err != nil {
+			return nil, err
+		}
+		for _, envArg := range strings.Split(environments, ",") {
+			envArg = strings.TrimSpace(envArg)
+			var names []string
+			if isPattern(envArg) {
+				for _, name := range SortedKeys(c.Services[service].Environments) {
+					if ok, err := path.Match(envArg, name); err != nil {
+						return nil, fmt.Errorf("invalid environment pattern '%s': %w", envArg, err)
+					} else if ok {
+						names = append(names, name)
+					}
+				}
+				if len(names) == 0 {
+					return nil, fmt.Errorf("no environment of service type '%s' matches '%s'", service, envArg)
+				}
+			} else {
+				name, err := c.ResolveEnvironment(service, envArg)
+				if err != nil {
+					return nil, err
+				}
+				names = []string{name}
+			}
+			for _, name := range names {
+				ref := TargetRef{Service: service, Environment: name}
+				if !seen[ref] {
+					seen[ref] = true
+					refs = append(refs, ref)
+				}
+			}
+		}
+	}
+	return refs, nil
+}
+
+// ExpandWorkspace resolves the entries of a workspace, given by name or
+// unique prefix, each a "service/environment" reference (expanded like
+// ExpandTargets) or a "bookmark:name".
+func (c *Config) ExpandWorkspace(name string) ([]TargetRef, error) {
+	name, err := c.ResolveWorkspace(name)
+	if err != nil {
+		return nil, err
+	}
+	entries := c.Workspaces[name]
+	var refs []TargetRef
+	seen := map[TargetRef]bool{}
+	for _, entry := range entries {
+		expanded, err := c.expandRef(entry)
+		if err != nil {
+			return nil, fmt.Errorf("in workspace '%s': %w", name, err)
+		}
+		for _, ref := range expanded {
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs, nil
+}
+
+// expandRef resolves a single workspace entry.
+func (c *Config) expandRef(entry string) ([]TargetRef, error) {
+	if name, ok := strings.CutPrefix(entry, "bookmark:"); ok {
+		name, err := c.ResolveBookmark(name)
+		if err != nil {
+			return nil, err
+		}
+		return []TargetRef{{Bookmark: name}}, nil
+	}
+	service, environment, ok := strings.Cut(entry, "/")
+	if !ok || service == "" || environment == "" {
+		return nil, fmt.Errorf("invalid reference '%s': expected service/environment or bookmark:name", entry)
+	}
+	return c.ExpandTargets(service, environment)
+}
+
+// isPattern reports whether an argument is a glob pattern.
+func isPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExpandTargets(t *testing.T) {
+	cfg := &Config{
+		Services: map[string]ServiceTypeConfig{
+			"logging": {Aliases: []string{"logs"}, Environments: ServiceEnvironments{"apps-prod": {Aliases: []string{"prod"}}, "data-prod": {}, "staging": {}}},
+			"gke":     {Environments: ServiceEnvironments{"apps-prod": {}, "dev": {}}},
+		},
+		Bookmarks: map[string]Bookmark{"dashboard": {URL: "https://console.cloud.google.com/monitoring"}},
+		Workspaces: map[string][]string{
+			"incident": {"logs/*-prod", "gke/apps-prod", "bookmark:dash", "logging/apps-prod"},
+			"broken":   {"logging"},
+		},
+	}
+	tests := []struct {
+		services, environments string
+		workspace              string
+		expected               string
+		expectedError          string
+	}{
+		{services: "logging", environments: "prod", expected: "[logging/apps-prod]"},
+		{services: "logging", environments: "prod,staging", expected: "[logging/apps-prod logging/staging]"},
+		{services: "logging", environments: "*-prod", expected: "[logging/apps-prod logging/data-prod]"},
+		{services: "logs,gke", environments: "apps-prod", expected: "[logging/apps-prod gke/apps-prod]"},
+		{services: "logging,gke", environments: "*-prod", expected: "[logging/apps-prod logging/data-prod gke/apps-prod]"},
+		{services: "logging", environments: "prod,apps-prod", expected: "[logging/apps-prod]"},
+		{services: "gke", environments: "*-staging", expectedError: "no environment of service type 'gke' matches '*-staging'"},
+		{services: "logging", environments: "[", expectedError: "invalid environment pattern '['"},
+		{services: "logging", environments: "prod,qa", expectedError: "environment 'qa' not found"},
+		{workspace: "incident", expected: "[logging/apps-prod logging/data-prod gke/apps-prod bookmark:dashboard]"},
+		{workspace: "inc", expected: "[logging/apps-prod logging/data-prod gke/apps-prod bookmark:dashboard]"},
+		{workspace: "broken", expectedError: "in workspace 'broken': invalid reference 'logging'"},
+		{workspace: "incidnet", expectedError: "workspace 'incidnet' not found in configuration (did you mean 'incident'?)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.services+" "+tt.environments+tt.workspace, func(t *testing.T) {
+			var refs []TargetRef
+			var err error
+			if tt.workspace != "" {
+				refs, err = cfg.ExpandWorkspace(tt.workspace)
+			} else {
+				refs, err = cfg.ExpandTargets(tt.services, tt.environments)
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("error = %v, want %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := fmt.Sprint(refs); got != tt.expected {
+				t.Errorf("expanded to %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of GCP identifiers checked by Validate. Region names are a
+// geography followed by a compass direction and a number (us-central1,
+// europe-west4, northamerica-northeast2), so that names such as api-v2 or
+// prod-cluster1 are not taken for regions.
+var (
+	ProjectIDPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	RegionPattern    = regexp.MustCompile(`^[a-z]+-` + directions + `[0-9]+$`)
+	ZonePattern      = regexp.MustCompile(`^[a-z]+-` + directions + `[0-9]+-[a-z]$`)
+)
+
+// directions are the compass directions in region names.
+const directions = `(central|north|south|east|west|northeast|northwest|southeast|southwest)`
+
+// ValidateAccount checks that an account is an email address or a
+// non-negative authuser index.
+func ValidateAccount(account string) error {
+	if n, err := strconv.Atoi(account); err == nil {
+		if n < 0 {
+			return fmt.Errorf("invalid account '%s': authuser index must not be negative", account)
+		}
+		return nil
+	}
+	if local, domain, ok := strings.Cut(account, "@"); !ok || local == "" || !strings.Contains(domain, ".") {
+		return fmt.Errorf("invalid account '%s': expected an email address or authuser index", account)
+	}
+	return nil
+}
+
+// Position is a location in a configuration file.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// ValidationError is a problem with the configuration at a position.
+type ValidationError struct {
+	Position
+	// Path is the dotted key path, e.g. services.cloudrun.environments.prod.region.
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	var sb strings.Builder
+	if e.File != "" {
+		sb.WriteString(e.File)
+		if e.Line > 0 {
+			fmt.Fprintf(&sb, ":%d:%d", e.Line, e.Column)
+		}
+		sb.WriteString(": ")
+	}
+	if e.Path != "" {
+		sb.WriteString(e.Path + ": ")
+	}
+	sb.WriteString(e.Message)
+	return sb.String()
+}
+
+// ValidationErrors collects several validation errors into one error.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ServiceRules reports the configuration keys a service type requires, and
+// whether the service type is known at all. It is supplied by the caller so
+// that the configuration package need not know the URL catalog.
+type ServiceRules func(serviceType string) (required []string, known bool)
+
+// Validate checks the loaded configuration beyond what parsing enforces:
+// keys must match a configuration field, every service type must be known or
+// have a url_template, url_templates must
+// parse, required keys must be set, aliases must be unambiguous, and project
+// IDs, regions, locations and accounts must be well-formed.
+func (c *Config) Validate(rules ServiceRules) ValidationErrors {
+	errs := append(ValidationErrors(nil), c.unknown...)
+	report := func(path, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Position: c.position(path), Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if c.Account != "" {
+		if err := ValidateAccount(c.Account); err != nil {
+			report("account", "%v", err)
+		}
+	}
+	for _, environment := range SortedKeys(c.Environments) {
+		checkFormats("environments."+environment, c.Environments[environment], EnvironmentConfig{}, report)
+	}
+	for i, ref := range c.Favourites {
+		var err error
+		if name, ok := strings.CutPrefix(ref, "bookmark:"); ok {
+			_, err = c.ResolveBookmark(name)
+		} else {
+			_, _, err = c.ResolveTarget(ref)
+		}
+		if err != nil {
+			report(fmt.Sprintf("favourites.%d", i), "%v", err)
+		}
+	}
+	for i, pattern := range c.Protected {
+		if _, err := path.Match(pattern, ""); err != nil {
+			report(fmt.Sprintf("protected.%d", i), "invalid pattern '%s': %v", pattern, err)
+		}
+	}
+	for _, name := range SortedKeys(c.Workspaces) {
+		for i, entry := range c.Workspaces[name] {
+			if _, err := c.expandRef(entry); err != nil {
+				report(fmt.Sprintf("workspaces.%s.%d", name, i), "%v", err)
+			}
+		}
+	}
+	for _, name := range SortedKeys(c.Bookmarks) {
+		bookmark := c.Bookmarks[name]
+		path := "bookmarks." + name
+		switch {
+		case bookmark.URL == "":
+			report(path, "bookmark has no url")
+		case strings.Contains(bookmark.URL, "{{"):
+			if _, err := template.New("url").Parse(bookmark.URL); err != nil {
+				report(path+".url", "invalid template: %v", err)
+			} else if bookmark.Environment == "" {
+				report(path+".url", "a templated url needs an environment to render against")
+			}
+		}
+		if _, ok := c.Environments[bookmark.Environment]; bookmark.Environment != "" && !ok {
+			report(path+".environment", "environment '%s' is not defined in the top-level environments", bookmark.Environment)
+		}
+	}
+	serviceAliases := map[string][]string{}
+	for service, serviceConfig := range c.Services {
+		serviceAliases[service] = serviceConfig.Aliases
+	}
+	checkAliases("services", serviceAliases, report)
+	for _, service := range SortedKeys(c.Services) {
+		serviceConfig := c.Services[service]
+		servicePath := "services." + service
+		envAliases := map[string][]string{}
+		for environment, envConfig := range serviceConfig.Environments {
+			envAliases[environment] = envConfig.Aliases
+		}
+		checkAliases(servicePath+".environments", envAliases, report)
+		required, known := rules(service)
+		if serviceConfig.URLTemplate != "" {
+			if _, err := template.New("url").Parse(serviceConfig.URLTemplate); err != nil {
+				report(servicePath+".url_template", "invalid template: %v", err)
+			}
+			required = []string{"project_id"}
+		} else if !known {
+			report(servicePath, "unknown service type '%s': not in the built-in catalog and no url_template defined", service)
+		}
+		for _, environment := range SortedKeys(serviceConfig.Environments) {
+			envConfig := serviceConfig.Environments[environment]
+			envPath := servicePath + ".environments." + environment
+			for _, key := range required {
+				if envField(envConfig, key) == "" {
+					report(envPath, "'%s' is required for service type '%s'", key, service)
+				}
+			}
+			var base EnvironmentConfig
+			if name, ok := c.bases[sourceKey(service, environment)]; ok {
+				base = c.Environments[name]
+			}
+			checkFormats(envPath, envConfig, base, report)
+		}
+	}
+	return errs
+}
+
+// checkAliases reports aliases that are also the name or alias of another
+// entry under path, which would make them resolve unpredictably.
+func checkAliases(path string, aliases map[string][]string, report func(path, format string, args ...interface{})) {
+	owners := map[string]string{}
+	for _, name := range SortedKeys(aliases) {
+		owners[name] = name
+	}
+	for _, name := range SortedKeys(aliases) {
+		for _, alias := range aliases[name] {
+			if owner, ok := owners[alias]; ok && owner != name {
+				report(path+"."+name+".aliases", "alias '%s' is already used by '%s'", alias, owner)
+				continue
+			}
+			owners[alias] = name
+		}
+	}
+}
+
+// checkFormats reports malformed project IDs, regions, locations and
+// accounts of the environment at path. Fields inherited unchanged from base
+// are skipped, as they are reported for the top-level environment.
+func checkFormats(path string, envConfig, base EnvironmentConfig, report func(path, format string, args ...interface{})) {
+	if v := envConfig.ProjectID; v != "" && v != base.ProjectID && !ProjectIDPattern.MatchString(v) {
+		report(path+".project_id", "'%s' is not a valid project ID (6-30 lowercase letters, digits or hyphens, starting with a letter)", v)
+	}
+	if v := envConfig.Region; v != "" && v != base.Region && !RegionPattern.MatchString(v) {
+		report(path+".region", "'%s' is not a valid region (e.g. us-central1)", v)
+	}
+	if v := envConfig.Location; v != "" && v != base.Location && !RegionPattern.MatchString(v) && !ZonePattern.MatchString(v) {
+		report(path+".location", "'%s' is not a valid region or zone (e.g. us-central1 or us-central1-a)", v)
+	}
+	if v := envConfig.Account; v != "" && v != base.Account {
+		if err := ValidateAccount(v); err != nil {
+			report(path+".account", "%v", err)
+		}
+	}
+	if v := envConfig.Authuser; v != "" && v != base.Authuser {
+		if err := ValidateAccount(v); err != nil {
+			report(path+".authuser", "%v", err)
+		}
+	}
+}
+
+// position returns the recorded position of a key path, falling back to its
+// closest recorded parent.
+func (c *Config) position(path string) Position {
+	for p := path; p != ""; {
+		if pos, ok := c.positions[p]; ok {
+			return pos
+		}
+		i := strings.LastIndex(p, ".")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	return Position{File: c.Path()}
+}
+
+// envField returns the value of the string field of an environment with the
+// given YAML key.
+func envField(envConfig EnvironmentConfig, key string) string {
+	v := reflect.ValueOf(envConfig)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if yamlKey(t.Field(i)) == key && t.Field(i).Type.Kind() == reflect.String {
+			return v.Field(i).String()
+		}
+	}
+	return ""
+}
+
+// yamlKey returns the YAML key of a struct field, or "" if it is not mapped.
+func yamlKey(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// recordPositions stores the position of every mapping key and list item
+// under node in positions, keyed by dotted path. Existing entries are kept,
+// so the highest-precedence file wins when layers are merged.
+func recordPositions(node *yaml.Node, file, prefix string, positions map[string]Position) {
+	if node.Kind == yaml.SequenceNode {
+		// List items are addressed by index, as in favourites.0
+		for i, item := range node.Content {
+			path := fmt.Sprintf("%s.%d", prefix, i)
+			if _, ok := positions[path]; !ok {
+				positions[path] = Position{File: file, Line: item.Line, Column: item.Column}
+			}
+			recordPositions(item, file, path, positions)
+		}
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := key.Value
+		if prefix != "" {
+			path = prefix + "." + key.Value
+		}
+		if _, ok := positions[path]; !ok {
+			positions[path] = Position{File: file, Line: key.Line, Column: key.Column}
+		}
+		recordPositions(value, file, path, positions)
+	}
+}
+
+// checkKnownFields reports every mapping key under node that has no
+// corresponding field in t, with its position and a suggestion.
+func checkKnownFields(node *yaml.Node, t reflect.Type, file, prefix string) ValidationErrors {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var errs ValidationErrors
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := map[string]reflect.Type{}
+		var names []string
+		for i := 0; i < t.NumField(); i++ {
+			if key := yamlKey(t.Field(i)); key != "" {
+				fields[key] = t.Field(i).Type
+				names = append(names, key)
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			path := joinPath(prefix, key.Value)
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown field '%s'", key.Value)
+				if suggestion := closest(key.Value, names); suggestion != "" {
+					msg += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+				}
+				errs = append(errs, ValidationError{Position: Position{File: file, Line: key.Line, Column: key.Column}, Path: path, Message: msg})
+				continue
+			}
+			errs = append(errs, checkKnownFields(value, fieldType, file, path)...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, checkKnownFields(node.Content[i+1], t.Elem(), file, joinPath(prefix, node.Content[i].Value))...)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			errs = append(errs, checkKnownFields(item, t.Elem(), file, joinPath(prefix, strconv.Itoa(i)))...)
+		}
+	}
+	return errs
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// closest returns the candidate nearest to word by edit distance, if it is
+// close enough to be a plausible typo.
+func closest(word string, candidates []string) string {
+	best, bestDistance := "", len(word)/2+2
+	for _, c := range candidates {
+		if d := levenshtein(strings.ToLower(word), strings.ToLower(c)); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
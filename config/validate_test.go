@@ -0,0 +1,218 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	content := `services:
+  logging:
+    environments:
+      prod:
+        projectid: prod-project
+        region: us-central1
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed on an unknown field: %v", err)
+	}
+	want := path + ":5:9: services.logging.environments.prod.projectid: unknown field 'projectid' (did you mean 'project_id'?)"
+	if !slices.Contains(cfg.Warnings(), want) {
+		t.Errorf("Warnings() = %q; want it to contain %q", cfg.Warnings(), want)
+	}
+	errs := cfg.Validate(func(string) ([]string, bool) { return nil, true })
+	if !strings.Contains(errs.Error(), want) {
+		t.Errorf("Validate() = %q; want it to contain %q", errs, want)
+	}
+}
+
+func TestLoadConfigEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err != nil {
+		t.Errorf("LoadConfig(empty) failed: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	rules := func(serviceType string) ([]string, bool) {
+		switch serviceType {
+		case "cloudrun":
+			return []string{"project_id", "region"}, true
+		case "logging":
+			return []string{"project_id"}, true
+		}
+		return nil, false
+	}
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "valid",
+			content: `account: me@example.com
+services:
+  cloudrun:
+    environments:
+      prod:
+        project_id: prod-project
+        region: us-central1
+  custom:
+    url_template: "https://example.com/{{.ProjectID}}"
+    environments:
+      prod:
+        project_id: prod-project
+`,
+		},
+		{
+			name: "missing required region",
+			content: `services:
+  cloudrun:
+    environments:
+      prod:
+        project_id: prod-project
+`,
+			expected: []string{":4:7: services.cloudrun.environments.prod: 'region' is required for service type 'cloudrun'"},
+		},
+		{
+			name: "bad formats",
+			content: `services:
+  logging:
+    environments:
+      prod:
+        project_id: Prod_Project
+        region: central
+        location: us-central1-zz
+        account: someone
+`,
+			expected: []string{
+				":5:9: services.logging.environments.prod.project_id: 'Prod_Project' is not a valid project ID",
+				":6:9: services.logging.environments.prod.region: 'central' is not a valid region",
+				":7:9: services.logging.environments.prod.location: 'us-central1-zz' is not a valid region or zone",
+				":8:9: services.logging.environments.prod.account: invalid account 'someone'",
+			},
+		},
+		{
+			name: "unknown service type and broken template",
+			content: `services:
+  mystery:
+    environments:
+      prod:
+        project_id: prod-project
+  custom:
+    url_template: "https://example.com/{{.ProjectID"
+`,
+			expected: []string{
+				":7:5: services.custom.url_template: invalid template",
+				":2:3: services.mystery: unknown service type 'mystery'",
+			},
+		},
+		{
+			name: "broken workspace",
+			content: `services:
+  logging:
+    environments:
+      prod:
+        project_id: prod-project
+workspaces:
+  incident:
+    - logging/prod
+    - logging/*-staging
+`,
+			expected: []string{":9:7: workspaces.incident.1: no environment of service type 'logging' matches '*-staging'"},
+		},
+		{
+			name: "protection",
+			content: `protected: ["[prod"]
+services:
+  logging:
+    environments:
+      prod:
+        project_id: prod-project
+        protected: true
+        authuser: viewer
+`,
+			expected: []string{
+				":1:13: protected.0: invalid pattern '[prod'",
+				":8:9: services.logging.environments.prod.authuser: invalid account 'viewer'",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			errs := cfg.Validate(rules)
+			if len(errs) != len(tt.expected) {
+				t.Fatalf("Validate() = %v; want %d errors", errs, len(tt.expected))
+			}
+			for i, want := range tt.expected {
+				if got := errs[i].Error(); !strings.Contains(got, path+want) {
+					t.Errorf("error %d = %q; want it to contain %q", i, got, path+want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAccount(t *testing.T) {
+	for _, account := range []string{"0", "3", "me@example.com"} {
+		if err := ValidateAccount(account); err != nil {
+			t.Errorf("ValidateAccount(%s) failed: %v", account, err)
+		}
+	}
+	for _, account := range []string{"-1", "me", "me@localhost", "@example.com"} {
+		if err := ValidateAccount(account); err == nil {
+			t.Errorf("ValidateAccount(%s) succeeded; want an error", account)
+		}
+	}
+}
+
+// TestSchemaCoversConfig checks that every YAML key of the configuration
+// structs is described by the JSON Schema.
+func TestSchemaCoversConfig(t *testing.T) {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	check := func(name string, properties map[string]json.RawMessage, v interface{}) {
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			if key := yamlKey(typ.Field(i)); key != "" {
+				if _, ok := properties[key]; !ok {
+					t.Errorf("schema %s does not describe '%s'", name, key)
+				}
+			}
+		}
+	}
+	check("root", schema.Properties, Config{})
+	check("service", schema.Defs["service"].Properties, ServiceTypeConfig{})
+	check("environment", schema.Defs["environment"].Properties, EnvironmentConfig{})
+	check("logQuery", schema.Defs["logQuery"].Properties, LogQuery{})
+	check("bookmark", schema.Defs["bookmark"].Properties, Bookmark{})
+}
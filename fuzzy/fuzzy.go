@@ -0,0 +1,125 @@
+// Package fuzzy ranks strings against a typed pattern the way command
+// palettes do: the pattern's characters must appear in order, and matches
+// that are contiguous or start words rank higher.
+package fuzzy
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Scores awarded and deducted while matching.
+const (
+	scoreMatch       = 16
+	bonusFirst       = 24
+	bonusWordStart   = 20
+	bonusConsecutive = 12
+	penaltyGap       = 2
+)
+
+// Match scores text against pattern, ignoring case. It reports whether every
+// character of pattern occurs in text in order and, if so, the score and the
+// rune positions in text that matched. An empty pattern matches everything
+// with a score of zero.
+func Match(pattern, text string) (int, []int, bool) {
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	bestScore, bestPositions, found := 0, []int(nil), false
+	// Try every occurrence of the first character as the start, so that
+	// "prod" prefers the "prod" in "apps-prod" over the scattered match
+	// starting at its "p".
+	for start := range t {
+		if !equalFold(t[start], p[0]) {
+			continue
+		}
+		positions := make([]int, 0, len(p))
+		positions = append(positions, start)
+		j := start + 1
+		for _, r := range p[1:] {
+			for j < len(t) && !equalFold(t[j], r) {
+				j++
+			}
+			if j == len(t) {
+				break
+			}
+			positions = append(positions, j)
+			j++
+		}
+		if len(positions) < len(p) {
+			// Later starts cannot match either
+			break
+		}
+		score := scorePositions(t, positions)
+		if !found || score > bestScore {
+			bestScore, bestPositions, found = score, positions, true
+		}
+	}
+	return bestScore, bestPositions, found
+}
+
+// scorePositions rates a set of matched positions in t.
+func scorePositions(t []rune, positions []int) int {
+	score := 0
+	for i, pos := range positions {
+		score += scoreMatch
+		switch {
+		case pos == 0:
+			score += bonusFirst
+		case isWordStart(t, pos):
+			score += bonusWordStart
+		}
+		if i > 0 {
+			if gap := pos - positions[i-1] - 1; gap == 0 {
+				score += bonusConsecutive
+			} else {
+				score -= gap * penaltyGap
+			}
+		}
+	}
+	// Shorter texts are closer matches
+	return score - (len(t) - len(positions))
+}
+
+func isWordStart(t []rune, pos int) bool {
+	prev := t[pos-1]
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev) ||
+		unicode.IsLower(prev) && unicode.IsUpper(t[pos])
+}
+
+func equalFold(a, b rune) bool {
+	return a == b || unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// Result is an item that matched a pattern.
+type Result struct {
+	// Index is the index of the item in the list that was filtered.
+	Index int
+	Score int
+	// Field is the index of the item's field that matched best, and
+	// Positions the rune positions of the match within it.
+	Field     int
+	Positions []int
+}
+
+// Filter matches pattern against the fields of n items, as returned by
+// fields, and returns those that match in any field, best first. Items that
+// score the same keep their order. An empty pattern returns every item.
+func Filter(pattern string, n int, fields func(i int) []string) []Result {
+	results := []Result{}
+	for i := 0; i < n; i++ {
+		best := Result{Index: i, Field: -1}
+		for f, text := range fields(i) {
+			if score, positions, ok := Match(pattern, text); ok && (best.Field < 0 || score > best.Score) {
+				best.Score, best.Field, best.Positions = score, f, positions
+			}
+		}
+		if best.Field >= 0 {
+			results = append(results, best)
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool { return results[a].Score > results[b].Score })
+	return results
+}
@@ -0,0 +1,63 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text     string
+		expectedPositions []int
+		expectMatch       bool
+	}{
+		{pattern: "", text: "anything", expectMatch: true},
+		{pattern: "prod", text: "myproject-prod", expectedPositions: []int{10, 11, 12, 13}, expectMatch: true},
+		{pattern: "PROD", text: "apps-prod", expectedPositions: []int{5, 6, 7, 8}, expectMatch: true},
+		{pattern: "mpd", text: "myproject-dev", expectedPositions: []int{0, 2, 10}, expectMatch: true},
+		{pattern: "dorp", text: "myproject-prod", expectMatch: false},
+		{pattern: "long-pattern", text: "long", expectMatch: false},
+	}
+	for _, tt := range tests {
+		_, positions, ok := Match(tt.pattern, tt.text)
+		if ok != tt.expectMatch {
+			t.Errorf("Match(%q, %q) matched = %v, want %v", tt.pattern, tt.text, ok, tt.expectMatch)
+			continue
+		}
+		if ok && !reflect.DeepEqual(positions, tt.expectedPositions) {
+			t.Errorf("Match(%q, %q) positions = %v, want %v", tt.pattern, tt.text, positions, tt.expectedPositions)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	items := [][]string{
+		{"spanner", "spanner-project"},
+		{"logging", "ops-project"},
+		{"cloudrun", "prod-project", "us-central1"},
+		{"cloudsql", "data-project"},
+	}
+	fields := func(i int) []string { return items[i] }
+
+	var names []string
+	for _, r := range Filter("clo", len(items), fields) {
+		names = append(names, items[r.Index][0])
+	}
+	if want := []string{"cloudrun", "cloudsql"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Filter(clo) = %v, want %v", names, want)
+	}
+
+	results := Filter("central", len(items), fields)
+	if len(results) != 1 || results[0].Index != 2 || results[0].Field != 2 {
+		t.Errorf("Filter(central) = %+v, want cloudrun matched on its region", results)
+	}
+
+	if n := len(Filter("", len(items), fields)); n != len(items) {
+		t.Errorf("Filter(\"\") returned %d items, want all %d", n, len(items))
+	}
+
+	results = Filter("lg", len(items), fields)
+	if len(results) != 1 || results[0].Index != 1 {
+		t.Errorf("Filter(lg) = %+v, want only logging", results)
+	}
+}
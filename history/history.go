@@ -0,0 +1,213 @@
+// Package history records launches in a JSON Lines file under
+// $XDG_STATE_HOME, so recently and frequently used targets can be offered
+// first.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Entry is a single recorded launch.
+type Entry struct {
+	Service     string `json:"service,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	// Bookmark is set instead of Service for a bookmarked URL.
+	Bookmark string    `json:"bookmark,omitempty"`
+	URL      string    `json:"url"`
+	Time     time.Time `json:"time"`
+}
+
+// Limits of the history file: once it grows past compactSize it is
+// rewritten with only the last MaxEntries launches, so that loading it for
+// every completion and TUI start stays cheap.
+const (
+	MaxEntries  = 1000
+	compactSize = 256 << 10
+)
+
+// Store is a history file.
+type Store struct {
+	path string
+	// maxEntries and compactSize are the limits of the file, see MaxEntries.
+	maxEntries  int
+	compactSize int64
+}
+
+// DefaultPath returns $XDG_STATE_HOME/gcp-launch/history.jsonl, defaulting
+// to ~/.local/state when XDG_STATE_HOME is unset.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot locate history: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "gcp-launch", "history.jsonl"), nil
+}
+
+// New returns the store at path.
+func New(path string) *Store {
+	return &Store{path: path, maxEntries: MaxEntries, compactSize: compactSize}
+}
+
+// Open returns the store at the default path.
+func Open() (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return New(path), nil
+}
+
+// Path returns the file the store reads and writes.
+func (s *Store) Path() string { return s.path }
+
+// Append records a launch.
+func (s *Store) Append(e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening history '%s': %w", s.path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing history '%s': %w", s.path, err)
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > s.compactSize {
+		return s.compact()
+	}
+	return nil
+}
+
+// compact rewrites the file with only its last maxEntries launches.
+func (s *Store) compact() error {
+	entries, err := s.Load()
+	if err != nil || len(entries) <= s.maxEntries {
+		return err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range entries[len(entries)-s.maxEntries:] {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".history-*")
+	if err != nil {
+		return fmt.Errorf("error compacting history '%s': %w", s.path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error compacting history '%s': %w", s.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error compacting history '%s': %w", s.path, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error compacting history '%s': %w", s.path, err)
+	}
+	return nil
+}
+
+// Load returns every recorded launch, oldest first. A missing file is an
+// empty history, and lines that cannot be parsed are skipped.
+func (s *Store) Load() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history '%s': %w", s.path, err)
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && (e.Service != "" || e.Bookmark != "") {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history '%s': %w", s.path, err)
+	}
+	return entries, nil
+}
+
+// Recent returns up to n of the most recently launched distinct targets,
+// most recent first.
+func Recent(entries []Entry, n int) []Entry {
+	seen := map[string]bool{}
+	var recent []Entry
+	for i := len(entries) - 1; i >= 0 && len(recent) < n; i-- {
+		key := TargetKey(entries[i])
+		if !seen[key] {
+			seen[key] = true
+			recent = append(recent, entries[i])
+		}
+	}
+	return recent
+}
+
+// TargetKey identifies the service type and environment of an entry, or
+// its bookmark.
+func TargetKey(e Entry) string {
+	if e.Bookmark != "" {
+		return "bookmark:" + e.Bookmark
+	}
+	return e.Service + "/" + e.Environment
+}
+
+// ServiceKey identifies the service type of an entry.
+func ServiceKey(e Entry) string { return e.Service }
+
+// Frecency scores how frequently and recently each key, as returned by key,
+// was launched: every launch adds a weight that decays with its age. Keys
+// that were never launched are absent, which is a score of zero.
+func Frecency(entries []Entry, now time.Time, key func(Entry) string) map[string]int {
+	scores := map[string]int{}
+	for _, e := range entries {
+		scores[key(e)] += recencyWeight(now.Sub(e.Time))
+	}
+	return scores
+}
+
+// recencyWeight is the weight of a launch of the given age, in buckets
+// similar to those browsers use to rank their address bar suggestions.
+func recencyWeight(age time.Duration) int {
+	const day = 24 * time.Hour
+	switch {
+	case age < 4*day:
+		return 100
+	case age < 14*day:
+		return 70
+	case age < 31*day:
+		return 50
+	case age < 90*day:
+		return 30
+	default:
+		return 10
+	}
+}
+
+// Rank sorts keys by descending score, keeping the order of keys that score
+// the same.
+func Rank(keys []string, scores map[string]int) {
+	sort.SliceStable(keys, func(i, j int) bool { return scores[keys[i]] > scores[keys[j]] })
+}
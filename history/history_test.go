@@ -0,0 +1,108 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "state", "history.jsonl"))
+	entries, err := store.Load()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Load() of missing history = %v, %v; want empty", entries, err)
+	}
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	launches := []Entry{
+		{Service: "logging", Environment: "prod", URL: "https://a", Time: start},
+		{Service: "cloudrun", Environment: "prod", URL: "https://b", Time: start.Add(time.Minute)},
+		{Service: "logging", Environment: "prod", URL: "https://a", Time: start.Add(2 * time.Minute)},
+		{Service: "gke", Environment: "dev", URL: "https://c", Time: start.Add(3 * time.Minute)},
+	}
+	for _, e := range launches {
+		if err := store.Append(e); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+	// A corrupt line must not lose the rest of the history
+	f, _ := os.OpenFile(store.Path(), os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("{not json\n")
+	f.Close()
+
+	entries, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(entries) != len(launches) {
+		t.Fatalf("Load() returned %d entries, want %d", len(entries), len(launches))
+	}
+	if !entries[3].Time.Equal(launches[3].Time) || entries[3].URL != "https://c" {
+		t.Errorf("Load()[3] = %+v, want %+v", entries[3], launches[3])
+	}
+
+	recent := Recent(entries, 2)
+	if len(recent) != 2 || recent[0].Service != "gke" || recent[1].Service != "logging" {
+		t.Errorf("Recent(2) = %+v, want gke/dev then logging/prod", recent)
+	}
+}
+
+func TestFrecency(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	entries := []Entry{
+		// Launched often, but long ago
+		{Service: "logging", Environment: "staging", Time: now.Add(-100 * day)},
+		{Service: "logging", Environment: "staging", Time: now.Add(-100 * day)},
+		{Service: "logging", Environment: "staging", Time: now.Add(-95 * day)},
+		// Launched once, recently
+		{Service: "logging", Environment: "prod", Time: now.Add(-time.Hour)},
+		// Launched twice in the last weeks
+		{Service: "gke", Environment: "prod", Time: now.Add(-10 * day)},
+		{Service: "gke", Environment: "prod", Time: now.Add(-20 * day)},
+	}
+	targets := Frecency(entries, now, TargetKey)
+	keys := []string{"cloudrun/prod", "logging/staging", "logging/prod", "gke/prod"}
+	Rank(keys, targets)
+	want := []string{"gke/prod", "logging/prod", "logging/staging", "cloudrun/prod"}
+	if strings.Join(keys, " ") != strings.Join(want, " ") {
+		t.Errorf("Rank() = %v, want %v (scores %v)", keys, want, targets)
+	}
+
+	services := Frecency(entries, now, ServiceKey)
+	if services["logging"] != 130 || services["gke"] != 120 {
+		t.Errorf("service frecency = %v, want logging 130 and gke 120", services)
+	}
+}
+
+func TestStoreCompacts(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "history.jsonl"))
+	store.maxEntries, store.compactSize = 10, 2048
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	const launches = 200
+	for i := 0; i < launches; i++ {
+		e := Entry{Service: "logging", Environment: "prod", URL: "https://console.cloud.google.com/logs/query?project=prod", Time: start.Add(time.Duration(i) * time.Minute)}
+		if err := store.Append(e); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+		if info, err := os.Stat(store.Path()); err != nil || info.Size() > store.compactSize+200 {
+			t.Fatalf("history grew to %d bytes after %d launches, want it compacted", info.Size(), i+1)
+		}
+	}
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(entries) < store.maxEntries || len(entries) >= launches {
+		t.Fatalf("Load() returned %d entries, want the last ones since compaction", len(entries))
+	}
+	for i, e := range entries {
+		if want := start.Add(time.Duration(launches-len(entries)+i) * time.Minute); !e.Time.Equal(want) {
+			t.Fatalf("entries[%d].Time = %v, want %v: the latest launches kept in order", i, e.Time, want)
+		}
+	}
+	if New("x").maxEntries != MaxEntries {
+		t.Errorf("New() keeps %d entries, want MaxEntries", New("x").maxEntries)
+	}
+}
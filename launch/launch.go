@@ -0,0 +1,221 @@
+// Package launch resolves a service type and environment from the
+// configuration into a console URL and opens it. It is shared by the CLI and
+// the TUI so both behave identically.
+package launch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/url"
+)
+
+// Options holds the per-invocation overrides of the configured environment.
+type Options struct {
+	// ContextArg is the optional context argument, see url.ApplyContextArg.
+	ContextArg string
+	// Tab and Revision select a page of a Cloud Run service (cloudrun only).
+	Tab      string
+	Revision string
+	// QueryName selects one of the environment's named log queries and Query
+	// is laid over it (logging only).
+	QueryName string
+	Query     *config.LogQuery
+	// Browser overrides the configured browser setting, see url.NewOpener.
+	Browser string
+	// Account overrides the configured Google account, see url.WithAccount.
+	Account string
+}
+
+// Target is a fully resolved launch: the effective environment configuration
+// after all overrides, and the URL generated from it.
+type Target struct {
+	Service     string
+	Environment string
+	// Bookmark is set instead of Service for a bookmarked URL.
+	Bookmark string
+	Config   config.EnvironmentConfig
+	// ContextParam is the configuration key the context argument set, if any.
+	ContextParam string
+	URL          string
+	// Browser is the browser setting the URL is opened with.
+	Browser string
+	// Protected targets must be confirmed before they are opened.
+	Protected bool
+}
+
+// Launcher resolves and opens targets from a loaded configuration.
+type Launcher struct {
+	cfg       *config.Config
+	newOpener func(browser string) url.Opener
+}
+
+// New returns a Launcher for the configuration.
+func New(cfg *config.Config) *Launcher {
+	return &Launcher{cfg: cfg, newOpener: url.NewOpener}
+}
+
+// Resolve looks up the service type and environment in the configuration,
+// applies the options and generates the console URL.
+func (l *Launcher) Resolve(service, environment string, opts Options) (Target, error) {
+	if l.cfg == nil {
+		return Target{}, fmt.Errorf("no configuration loaded")
+	}
+	// Aliases and prefixes resolve to the configured names
+	service, err := l.cfg.ResolveService(service)
+	if err != nil {
+		return Target{}, err
+	}
+	environment, err = l.cfg.ResolveEnvironment(service, environment)
+	if err != nil {
+		return Target{}, err
+	}
+	serviceConfig := l.cfg.Services[service]
+	envConfig := serviceConfig.Environments[environment]
+	if envConfig.ProjectID == "" {
+		return Target{}, fmt.Errorf("project_id not defined for service type '%s' in environment '%s'", service, environment)
+	}
+	target := Target{Service: service, Environment: environment}
+
+	if opts.ContextArg != "" {
+		envConfig, target.ContextParam, err = url.ApplyContextArg(service, serviceConfig, envConfig, opts.ContextArg)
+		if err != nil {
+			return Target{}, err
+		}
+	}
+	if opts.Tab != "" || opts.Revision != "" {
+		if service != "cloudrun" {
+			return Target{}, fmt.Errorf("a tab or revision is only supported for service type 'cloudrun'")
+		}
+		if opts.Tab != "" {
+			envConfig.Tab = opts.Tab
+		}
+		if opts.Revision != "" {
+			envConfig.Revision = opts.Revision
+		}
+	}
+	if opts.QueryName != "" || opts.Query != nil {
+		if service != "logging" {
+			return Target{}, fmt.Errorf("log queries are only supported for service type 'logging'")
+		}
+		query, err := resolveQuery(envConfig, environment, opts)
+		if err != nil {
+			return Target{}, err
+		}
+		envConfig.Query = query
+	}
+	envConfig.Account = firstNonEmpty(opts.Account, envConfig.Account, l.cfg.Account)
+
+	serviceURL, err := url.GenerateServiceURL(service, serviceConfig, envConfig)
+	if err != nil {
+		return Target{}, fmt.Errorf("failed to generate URL: %w", err)
+	}
+	target.Config = envConfig
+	target.URL = serviceURL
+	target.Browser = firstNonEmpty(opts.Browser, envConfig.Browser, l.cfg.Browser)
+	target.Protected = l.cfg.IsProtected(service, environment)
+	return target, nil
+}
+
+// ResolveBookmark looks up a bookmark, or a unique prefix of one, renders
+// its URL against its environment and applies the account and browser
+// settings. Only the Account and Browser options apply to bookmarks.
+func (l *Launcher) ResolveBookmark(name string, opts Options) (Target, error) {
+	if l.cfg == nil {
+		return Target{}, fmt.Errorf("no configuration loaded")
+	}
+	name, err := l.cfg.ResolveBookmark(name)
+	if err != nil {
+		return Target{}, err
+	}
+	bookmark := l.cfg.Bookmarks[name]
+	target := Target{Bookmark: name, Environment: bookmark.Environment}
+	var envConfig config.EnvironmentConfig
+	if bookmark.Environment != "" {
+		var ok bool
+		if envConfig, ok = l.cfg.Environments[bookmark.Environment]; !ok {
+			return Target{}, fmt.Errorf("environment '%s' of bookmark '%s' not found in configuration", bookmark.Environment, name)
+		}
+	}
+	bookmarkURL := bookmark.URL
+	if strings.Contains(bookmarkURL, "{{") {
+		if bookmarkURL, err = url.GenerateTemplateURL(bookmarkURL, envConfig); err != nil {
+			return Target{}, fmt.Errorf("failed to generate URL for bookmark '%s': %w", name, err)
+		}
+	}
+	envConfig.Account = firstNonEmpty(opts.Account, envConfig.Account, l.cfg.Account)
+	if bookmarkURL, err = url.WithAccount(bookmarkURL, envConfig.Account); err != nil {
+		return Target{}, fmt.Errorf("failed to generate URL for bookmark '%s': %w", name, err)
+	}
+	target.Config = envConfig
+	target.URL = bookmarkURL
+	target.Browser = firstNonEmpty(opts.Browser, envConfig.Browser, l.cfg.Browser)
+	target.Protected = bookmark.Environment != "" && l.cfg.IsProtected("", bookmark.Environment)
+	return target, nil
+}
+
+// ResolveRef resolves a reference to an environment of a service type or to
+// a bookmark, see Resolve and ResolveBookmark.
+func (l *Launcher) ResolveRef(ref config.TargetRef, opts Options) (Target, error) {
+	if ref.Bookmark != "" {
+		return l.ResolveBookmark(ref.Bookmark, opts)
+	}
+	return l.Resolve(ref.Service, ref.Environment, opts)
+}
+
+// ReadOnly returns the target switched to its environment's authuser, the
+// account a protected environment can be opened as without confirmation.
+func (l *Launcher) ReadOnly(target Target) (Target, error) {
+	if target.Config.Authuser == "" {
+		return Target{}, fmt.Errorf("no authuser configured for environment '%s'", target.Environment)
+	}
+	readOnlyURL, err := url.WithAccount(target.URL, target.Config.Authuser)
+	if err != nil {
+		return Target{}, err
+	}
+	target.URL = readOnlyURL
+	target.Config.Account = target.Config.Authuser
+	return target, nil
+}
+
+// Open opens the target's URL with the target's browser setting.
+func (l *Launcher) Open(target Target) error {
+	if target.URL == "" {
+		if target.Bookmark != "" {
+			return fmt.Errorf("no URL resolved for bookmark '%s'", target.Bookmark)
+		}
+		return fmt.Errorf("no URL resolved for service type '%s' in environment '%s'", target.Service, target.Environment)
+	}
+	return l.newOpener(target.Browser).Open(target.URL)
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// resolveQuery combines the environment's default query, the named query and
+// the query overrides, in that order of increasing precedence.
+func resolveQuery(envConfig config.EnvironmentConfig, environment string, opts Options) (config.LogQuery, error) {
+	query := envConfig.Query
+	if opts.QueryName != "" {
+		named, ok := envConfig.Queries[opts.QueryName]
+		if !ok {
+			if len(envConfig.Queries) == 0 {
+				return config.LogQuery{}, fmt.Errorf("query '%s' not found: no queries defined for environment '%s'", opts.QueryName, environment)
+			}
+			return config.LogQuery{}, fmt.Errorf("query '%s' not found for environment '%s' (available: %s)", opts.QueryName, environment, strings.Join(config.SortedKeys(envConfig.Queries), ", "))
+		}
+		query = query.Merge(named)
+	}
+	if opts.Query != nil {
+		query = query.Merge(*opts.Query)
+	}
+	return query, nil
+}
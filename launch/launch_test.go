@@ -0,0 +1,243 @@
+package launch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/url"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Services: map[string]config.ServiceTypeConfig{
+			"logging": {Environments: map[string]config.EnvironmentConfig{
+				"prod": {
+					ProjectID: "prod-project",
+					Queries:   map[string]config.LogQuery{"errors": {Severity: "ERROR"}},
+				},
+			}},
+			"cloudrun": {Environments: map[string]config.EnvironmentConfig{
+				"prod":      {ProjectID: "prod-project", Region: "us-central1"},
+				"no-region": {ProjectID: "prod-project"},
+			}},
+			"bigquery": {Environments: map[string]config.EnvironmentConfig{
+				"prod": {ProjectID: "prod-project"},
+				"none": {},
+			}},
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		service     string
+		environment string
+		opts        Options
+		expectedURL string
+		expectError bool
+	}{
+		{
+			name:        "catalog service",
+			service:     "bigquery",
+			environment: "prod",
+			expectedURL: "https://console.cloud.google.com/bigquery?project=prod-project",
+		},
+		{
+			name:        "cloudrun service and tab",
+			service:     "cloudrun",
+			environment: "prod",
+			opts:        Options{ContextArg: "checkout-api", Tab: "logs"},
+			expectedURL: "https://console.cloud.google.com/run/detail/us-central1/checkout-api/logs?project=prod-project",
+		},
+		{
+			name:        "named log query with override",
+			service:     "logging",
+			environment: "prod",
+			opts:        Options{QueryName: "errors", Query: &config.LogQuery{Since: "1h"}},
+			expectedURL: "https://console.cloud.google.com/logs/query;query=severity%3E%3DERROR;duration=PT1H?project=prod-project",
+		},
+		{
+			name:        "account override",
+			service:     "bigquery",
+			environment: "prod",
+			opts:        Options{Account: "3"},
+			expectedURL: "https://console.cloud.google.com/bigquery?project=prod-project&authuser=3",
+		},
+		{name: "unknown service", service: "nope", environment: "prod", expectError: true},
+		{name: "unknown environment", service: "bigquery", environment: "nope", expectError: true},
+		{name: "missing project", service: "bigquery", environment: "none", expectError: true},
+		{name: "cloudrun without region", service: "cloudrun", environment: "no-region", expectError: true},
+		{name: "tab on non-cloudrun", service: "bigquery", environment: "prod", opts: Options{Tab: "logs"}, expectError: true},
+		{name: "query on non-logging", service: "bigquery", environment: "prod", opts: Options{QueryName: "errors"}, expectError: true},
+		{name: "unknown query", service: "logging", environment: "prod", opts: Options{QueryName: "audit"}, expectError: true},
+	}
+
+	launcher := New(testConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := launcher.Resolve(tt.service, tt.environment, tt.opts)
+			if (err != nil) != tt.expectError {
+				t.Errorf("Resolve() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if target.URL != tt.expectedURL {
+				t.Errorf("Resolve() got URL = %v, want %v", target.URL, tt.expectedURL)
+			}
+		})
+	}
+}
+
+// fakeOpener records the URLs it is asked to open.
+type fakeOpener struct {
+	opened []string
+	err    error
+}
+
+func (o *fakeOpener) Open(u string) error {
+	o.opened = append(o.opened, u)
+	return o.err
+}
+
+func TestResolveAccount(t *testing.T) {
+	cfg := testConfig()
+	cfg.Account = "me@example.com"
+	bq := cfg.Services["bigquery"]
+	bq.Environments["ops"] = config.EnvironmentConfig{ProjectID: "ops-project", Account: "1"}
+	launcher := New(cfg)
+
+	target, _ := launcher.Resolve("bigquery", "prod", Options{})
+	if want := "https://console.cloud.google.com/bigquery?project=prod-project&authuser=me%40example.com"; target.URL != want {
+		t.Errorf("Resolve() with global account got URL = %v, want %v", target.URL, want)
+	}
+	target, _ = launcher.Resolve("bigquery", "ops", Options{})
+	if want := "https://console.cloud.google.com/bigquery?project=ops-project&authuser=1"; target.URL != want {
+		t.Errorf("Resolve() with environment account got URL = %v, want %v", target.URL, want)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	cfg := testConfig()
+	cfg.Browser = "firefox {{.URL}}"
+	bq := cfg.Services["bigquery"]
+	bq.Environments["work"] = config.EnvironmentConfig{ProjectID: "work-project", Browser: "google-chrome --profile-directory=Work"}
+
+	opener := &fakeOpener{}
+	var browsers []string
+	launcher := New(cfg)
+	launcher.newOpener = func(browser string) url.Opener {
+		browsers = append(browsers, browser)
+		return opener
+	}
+
+	target, err := launcher.Resolve("bigquery", "prod", Options{})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if err := launcher.Open(target); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(opener.opened) != 1 || opener.opened[0] != target.URL {
+		t.Errorf("Open() opened %v, want [%s]", opener.opened, target.URL)
+	}
+
+	work, _ := launcher.Resolve("bigquery", "work", Options{})
+	override, _ := launcher.Resolve("bigquery", "work", Options{Browser: "$BROWSER"})
+	_ = launcher.Open(work)
+	_ = launcher.Open(override)
+	want := []string{"firefox {{.URL}}", "google-chrome --profile-directory=Work", "$BROWSER"}
+	if strings.Join(browsers, "|") != strings.Join(want, "|") {
+		t.Errorf("Open() used browsers %q, want %q", browsers, want)
+	}
+
+	opener.err = errors.New("no browser")
+	if err := launcher.Open(target); err == nil {
+		t.Error("Open() expected error from opener, got nil")
+	}
+	if err := launcher.Open(Target{}); err == nil {
+		t.Error("Open() expected error for unresolved target, got nil")
+	}
+}
+
+func TestResolveBookmark(t *testing.T) {
+	cfg := testConfig()
+	cfg.Account = "me@example.com"
+	cfg.Environments = map[string]config.EnvironmentConfig{
+		"prod": {ProjectID: "prod-project", Account: "1", Browser: "firefox"},
+	}
+	cfg.Bookmarks = map[string]config.Bookmark{
+		"orders-table":     {URL: "https://console.cloud.google.com/bigquery?project={{.ProjectID}}&p={{.ProjectID}}&d=orders", Environment: "prod"},
+		"billing":          {URL: "https://console.cloud.google.com/billing"},
+		"broken-template":  {URL: "https://console.cloud.google.com/{{.Nope}}", Environment: "prod"},
+		"orders-dashboard": {URL: "https://console.cloud.google.com/monitoring/dashboards/custom/1#section"},
+	}
+	launcher := New(cfg)
+	tests := []struct {
+		name            string
+		expectedURL     string
+		expectedBrowser string
+		expectError     bool
+	}{
+		{name: "orders-t", expectedURL: "https://console.cloud.google.com/bigquery?project=prod-project&p=prod-project&d=orders&authuser=1", expectedBrowser: "firefox"},
+		{name: "billing", expectedURL: "https://console.cloud.google.com/billing?authuser=me%40example.com"},
+		{name: "orders-dashboard", expectedURL: "https://console.cloud.google.com/monitoring/dashboards/custom/1?authuser=me%40example.com#section"},
+		{name: "orders", expectError: true},
+		{name: "broken-template", expectError: true},
+		{name: "nope", expectError: true},
+	}
+	for _, tt := range tests {
+		target, err := launcher.ResolveBookmark(tt.name, Options{})
+		if (err != nil) != tt.expectError {
+			t.Errorf("ResolveBookmark(%s) error = %v, expectError %v", tt.name, err, tt.expectError)
+			continue
+		}
+		if target.URL != tt.expectedURL || target.Browser != tt.expectedBrowser {
+			t.Errorf("ResolveBookmark(%s) = %s with browser %q, want %s with browser %q", tt.name, target.URL, target.Browser, tt.expectedURL, tt.expectedBrowser)
+		}
+	}
+}
+
+func TestResolveProtected(t *testing.T) {
+	cfg := testConfig()
+	cfg.Account = "me@example.com"
+	cfg.Protected = []string{"cloudrun/*"}
+	cfg.Environments = map[string]config.EnvironmentConfig{
+		"prod": {ProjectID: "prod-project", Protected: true},
+	}
+	cfg.Bookmarks = map[string]config.Bookmark{
+		"billing": {URL: "https://console.cloud.google.com/billing?project={{.ProjectID}}", Environment: "prod"},
+	}
+	bq := cfg.Services["bigquery"]
+	bq.Environments["prod"] = config.EnvironmentConfig{ProjectID: "prod-project", Protected: true, Authuser: "ro@example.com"}
+	launcher := New(cfg)
+
+	for _, tt := range []struct {
+		service, environment string
+		protected            bool
+	}{
+		{"bigquery", "prod", true},
+		{"bigquery", "none", false},
+		{"cloudrun", "prod", true},
+		{"logging", "prod", false},
+	} {
+		target, _ := launcher.Resolve(tt.service, tt.environment, Options{})
+		if target.Protected != tt.protected {
+			t.Errorf("Resolve(%s, %s).Protected = %v, want %v", tt.service, tt.environment, target.Protected, tt.protected)
+		}
+	}
+	if target, _ := launcher.ResolveBookmark("billing", Options{}); !target.Protected {
+		t.Errorf("ResolveBookmark(billing).Protected = false, want true")
+	}
+
+	target, _ := launcher.Resolve("bigquery", "prod", Options{})
+	readOnly, err := launcher.ReadOnly(target)
+	if want := "https://console.cloud.google.com/bigquery?project=prod-project&authuser=ro%40example.com"; err != nil || readOnly.URL != want {
+		t.Errorf("ReadOnly() = %v, %v; want URL %v", readOnly.URL, err, want)
+	}
+	target, _ = launcher.Resolve("cloudrun", "prod", Options{})
+	if _, err := launcher.ReadOnly(target); err == nil {
+		t.Errorf("ReadOnly() without authuser expected error, got nil")
+	}
+}
@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/fuzzy"
+)
+
+var (
+	// matchStyle highlights the characters a filter matched.
+	matchStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	// detailStyle renders secondary information such as the project ID.
+	detailStyle = lipgloss.NewStyle().Faint(true)
+	// productionStyle marks production environments.
+	productionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	// bannerStyle warns about protected environments.
+	bannerStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")).Background(lipgloss.Color("9"))
+)
+
+// itemFields returns the texts the filter is matched against for the i-th
+// item of the current list: the key and its aliases, and for environments
+// the project, region, location, cluster, tags and label values. Palette items are keyed by
+// service type and environment separated by a space, and bookmarks by
+// "bookmark" and their name, matching also on their tags and environment.
+func (m Model) itemFields(i int) []string {
+	var key string
+	var envConfig config.EnvironmentConfig
+	switch m.state {
+	case statePalette:
+		item := m.paletteItems[i]
+		if item.bookmark != "" {
+			bookmark := m.cfg.Bookmarks[item.bookmark]
+			fields := append([]string{"bookmark " + item.bookmark}, bookmark.Tags...)
+			if bookmark.Environment != "" {
+				fields = append(fields, bookmark.Environment)
+			}
+			return fields
+		}
+		key = item.service + " " + item.environment
+		envConfig = m.cfg.Services[item.service].Environments[item.environment]
+	case stateSelectService:
+		key = m.serviceKeys[i]
+		return append([]string{key}, m.cfg.Services[key].Aliases...)
+	default:
+		key = m.environmentKeys[i]
+		envConfig = m.cfg.Services[m.selectedService].Environments[key]
+	}
+	fields := append([]string{key}, envConfig.Aliases...)
+	for _, f := range []string{envConfig.ProjectID, envConfig.Region, envConfig.Location, envConfig.Cluster} {
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	fields = append(fields, envConfig.Tags...)
+	for _, key := range config.SortedKeys(envConfig.Labels) {
+		fields = append(fields, envConfig.Labels[key])
+	}
+	return fields
+}
+
+// production reports whether the i-th item of the current list is a
+// production environment.
+func (m Model) production(i int) bool {
+	switch m.state {
+	case statePalette:
+		item := m.paletteItems[i]
+		return item.bookmark == "" && m.cfg.Services[item.service].Environments[item.environment].Production()
+	case stateSelectEnvironment:
+		return m.cfg.Services[m.selectedService].Environments[m.environmentKeys[i]].Production()
+	}
+	return false
+}
+
+// refilter recomputes the visible items of the current list from the filter
+// and keeps the cursor within them.
+func (m *Model) refilter() {
+	var n int
+	switch m.state {
+	case statePalette:
+		n = len(m.paletteItems)
+	case stateSelectService:
+		n = len(m.serviceKeys)
+	default:
+		n = len(m.environmentKeys)
+	}
+	m.matches = fuzzy.Filter(m.filter, n, m.itemFields)
+	cursor := m.cursor()
+	if *cursor >= len(m.matches) {
+		*cursor = len(m.matches) - 1
+	}
+	if *cursor < 0 {
+		*cursor = 0
+	}
+}
+
+// resetFilter clears the filter and leaves filter input.
+func (m *Model) resetFilter() {
+	m.filtering = false
+	m.filter = ""
+	m.refilter()
+}
+
+// cursor returns the cursor of the current list.
+func (m *Model) cursor() *int {
+	switch m.state {
+	case statePalette:
+		return &m.paletteCursor
+	case stateSelectEnvironment:
+		return &m.environmentCursor
+	default:
+		return &m.serviceCursor
+	}
+}
+
+// renderMatch renders a visible item, highlighting the matched
+// characters, with production environments in productionStyle. An item
+// matched on anything but its key shows the matched text after the key.
+func (m Model) renderMatch(r fuzzy.Result) string {
+	fields := m.itemFields(r.Index)
+	style := lipgloss.NewStyle()
+	if m.production(r.Index) {
+		style = productionStyle
+	}
+	if r.Field <= 0 {
+		return highlight(fields[0], r.Positions, style)
+	}
+	return highlight(fields[0], nil, style) + "  " + detailStyle.Render("(") + highlight(fields[r.Field], r.Positions, lipgloss.NewStyle()) + detailStyle.Render(")")
+}
+
+// highlight renders the runes of s at positions in matchStyle and the rest
+// in style.
+func highlight(s string, positions []int, style lipgloss.Style) string {
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+	var sb, run strings.Builder
+	flush := func() {
+		if run.Len() > 0 {
+			sb.WriteString(style.Render(run.String()))
+			run.Reset()
+		}
+	}
+	for i, r := range []rune(s) {
+		if matched[i] {
+			flush()
+			sb.WriteString(matchStyle.Render(string(r)))
+		} else {
+			run.WriteRune(r)
+		}
+	}
+	flush()
+	return sb.String()
+}
@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/history"
+)
+
+// Sections of the palette, in the order they are listed.
+const (
+	pinFavourite = "Favourites"
+	pinRecent    = "Recent"
+	pinBookmarks = "Bookmarks"
+	pinAll       = "All"
+)
+
+// recentLimit is how many recently used targets the palette lists first.
+const recentLimit = 5
+
+// paletteItem is a service type and environment pair, or a bookmark, in
+// the palette.
+type paletteItem struct {
+	service     string
+	environment string
+	bookmark    string
+	// section is the palette section the item is listed in.
+	section string
+}
+
+// WithHistory returns the model with the recently launched targets listed in
+// the palette after the favourites, most recent first, and every list ranked
+// by frecency.
+func (m Model) WithHistory(entries []history.Entry) Model {
+	now := time.Now()
+	m.recent = history.Recent(entries, recentLimit)
+	m.targetScores = history.Frecency(entries, now, history.TargetKey)
+	history.Rank(m.serviceKeys, history.Frecency(entries, now, history.ServiceKey))
+	m.buildPalette()
+	m.refilter()
+	return m
+}
+
+// buildPalette lists every service type and environment pair and every
+// bookmark: favourites first, then recently used targets, then bookmarks,
+// then everything else by frecency.
+func (m *Model) buildPalette() {
+	m.paletteItems = nil
+	if m.cfg == nil {
+		return
+	}
+	listed := map[string]bool{}
+	add := func(service, environment, section string) {
+		key := service + "/" + environment
+		if listed[key] {
+			return
+		}
+		if env, ok := m.cfg.Services[service].Environments[environment]; !ok || !m.environmentFilter.Matches(env) {
+			return
+		}
+		listed[key] = true
+		m.paletteItems = append(m.paletteItems, paletteItem{service: service, environment: environment, section: section})
+	}
+	addBookmark := func(name, section string) {
+		if listed["bookmark:"+name] {
+			return
+		}
+		if _, ok := m.cfg.Bookmarks[name]; !ok || !m.environmentFilter.Empty() {
+			return
+		}
+		listed["bookmark:"+name] = true
+		m.paletteItems = append(m.paletteItems, paletteItem{bookmark: name, section: section})
+	}
+	for _, ref := range m.cfg.Favourites {
+		// Invalid favourites are reported by config validate
+		if name, ok := strings.CutPrefix(ref, "bookmark:"); ok {
+			if name, err := m.cfg.ResolveBookmark(name); err == nil {
+				addBookmark(name, pinFavourite)
+			}
+		} else if service, environment, err := m.cfg.ResolveTarget(ref); err == nil {
+			add(service, environment, pinFavourite)
+		}
+	}
+	for _, e := range m.recent {
+		if e.Bookmark != "" {
+			addBookmark(e.Bookmark, pinRecent)
+		} else {
+			add(e.Service, e.Environment, pinRecent)
+		}
+	}
+	for _, name := range config.SortedKeys(m.cfg.Bookmarks) {
+		addBookmark(name, pinBookmarks)
+	}
+	var rest []string
+	for _, service := range m.serviceKeys {
+		for _, environment := range config.SortedKeys(m.cfg.Services[service].Environments) {
+			rest = append(rest, service+"/"+environment)
+		}
+	}
+	history.Rank(rest, m.targetScores)
+	for _, key := range rest {
+		service, environment, _ := strings.Cut(key, "/")
+		add(service, environment, pinAll)
+	}
+}
+
+// updatePalette handles keys in the palette, where typing always filters
+// and space toggles the selection.
+func (m Model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		if m.filter == "" {
+			return m, tea.Quit
+		}
+		m.resetFilter()
+	case "tab":
+		// Switch to the step-by-step service and environment lists
+		m.state = stateSelectService
+		m.resetFilter()
+	case " ":
+		m.toggleCurrent()
+	case "enter", "ctrl+y":
+		if len(m.selected) > 0 {
+			return m.launchSelection(msg.String() == "ctrl+y")
+		}
+		cursor := m.paletteCursor
+		if cursor >= 0 && cursor < len(m.matches) {
+			item := m.paletteItems[m.matches[cursor].Index]
+			if item.bookmark != "" {
+				return m.launchBookmark(item.bookmark, msg.String() == "ctrl+y")
+			}
+			m.selectedService = item.service
+			return m.launchSelected(item.environment, msg.String() == "ctrl+y")
+		}
+	case "up", "ctrl+p":
+		m.moveCursor(-1)
+	case "down", "ctrl+n":
+		m.moveCursor(1)
+	case "backspace":
+		if runes := []rune(m.filter); len(runes) > 0 {
+			m.filter = string(runes[:len(runes)-1])
+			m.refilter()
+		}
+	default:
+		if msg.Type == tea.KeyRunes {
+			m.filter += string(msg.Runes)
+			m.refilter()
+		}
+	}
+	return m, nil
+}
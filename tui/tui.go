@@ -9,24 +9,76 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/tom-gray/gcp-launch/config"
-	"github.com/tom-gray/gcp-launch/url"
+	"github.com/tom-gray/gcp-launch/fuzzy"
+	"github.com/tom-gray/gcp-launch/history"
+	"github.com/tom-gray/gcp-launch/launch"
 )
 
+// ungrouped heads the environments without the group label.
+const ungrouped = "Other"
+
 const (
+	statePalette           = "palette"
 	stateSelectService     = "select_service"
 	stateSelectEnvironment = "select_environment"
+	stateConfirm           = "confirm"
 )
 
 type Model struct {
 	cfg               *config.Config
+	launcher          *launch.Launcher
 	state             string
 	serviceKeys       []string
 	serviceCursor     int
 	selectedService   string
 	environmentKeys   []string
 	environmentCursor int
-	finalURL          string
-	finalError        error
+	// environmentFilter restricts every list to the environments it
+	// matches; groups maps the listed environments to their group, if any
+	// of them has the group label.
+	environmentFilter config.EnvironmentFilter
+	groups            map[string]string
+	// paletteItems lists every service and environment pair for the
+	// palette, the default view; recent are the recently used targets.
+	paletteItems  []paletteItem
+	paletteCursor int
+	recent        []history.Entry
+	// targetScores ranks environments by frecency, keyed by
+	// history.TargetKey.
+	targetScores map[string]int
+	// height is the terminal height, to scroll long lists.
+	height int
+	// filtering is set while a filter is being typed; filter is the pattern
+	// and matches the items of the current list that it matches, best first.
+	// The cursors index into matches.
+	filtering bool
+	filter    string
+	matches   []fuzzy.Result
+	// selected holds the targets toggled with space, in selection order, to
+	// be launched together.
+	selected      []config.TargetRef
+	launchOptions launch.Options
+	// noOpen makes a selection only resolve the target, for --print and --dry-run.
+	noOpen bool
+	// noConfirm opens protected environments without confirmation, and
+	// readOnly as their authuser instead, for --yes and --read-only.
+	noConfirm bool
+	readOnly  bool
+	// pending are the targets being confirmed in stateConfirm, which
+	// returns to returnState if cancelled. confirmInput is the name typed
+	// and confirmError the outcome of the last attempt.
+	pending      []launch.Target
+	returnState  string
+	confirmInput string
+	confirmError string
+	// yanked records that the selection should be copied rather than opened.
+	yanked      bool
+	finalTarget launch.Target
+	// finalTargets are all the targets of a multiple selection, which the
+	// caller opens.
+	finalTargets []launch.Target
+	finalURL     string
+	finalError   error
 }
 
 func NewModel(cfg *config.Config) Model {
@@ -38,9 +90,10 @@ func NewModel(cfg *config.Config) Model {
 		}
 		sort.Strings(keys)
 	}
-	return Model{
+	m := Model{
 		cfg:               cfg,
-		state:             stateSelectService,
+		launcher:          launch.New(cfg),
+		state:             statePalette,
 		serviceKeys:       keys,
 		serviceCursor:     0,
 		selectedService:   "",
@@ -49,7 +102,61 @@ func NewModel(cfg *config.Config) Model {
 		finalURL:          "",
 		finalError:        nil,
 	}
+	m.buildPalette()
+	m.refilter()
+	return m
+}
+
+// WithLaunchOptions returns the model with options (such as the browser or
+// account) applied to every launch.
+func (m Model) WithLaunchOptions(opts launch.Options) Model {
+	m.launchOptions = opts
+	return m
+}
+
+// WithEnvironmentFilter returns the model listing only the environments
+// that match the filter, and the service types that have any.
+func (m Model) WithEnvironmentFilter(filter config.EnvironmentFilter) Model {
+	m.environmentFilter = filter
+	if m.cfg == nil || filter.Empty() {
+		return m
+	}
+	keys := m.serviceKeys[:0:0]
+	for _, key := range m.serviceKeys {
+		for _, env := range m.cfg.Services[key].Environments {
+			if filter.Matches(env) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	m.serviceKeys = keys
+	m.buildPalette()
+	m.refilter()
+	return m
+}
+
+// WithoutOpening returns the model that resolves the selected target and
+// quits without opening it in a browser.
+func (m Model) WithoutOpening() Model {
+	m.noOpen = true
+	return m
 }
+
+// WithoutConfirmation returns the model that opens protected environments
+// without asking for confirmation.
+func (m Model) WithoutConfirmation() Model {
+	m.noConfirm = true
+	return m
+}
+
+// WithReadOnly returns the model that opens protected environments as
+// their authuser, where one is configured, without confirmation.
+func (m Model) WithReadOnly() Model {
+	m.readOnly = true
+	return m
+}
+
 func (m Model) Init() tea.Cmd { return nil }
 
 // Update handles messages and state transitions.
@@ -59,159 +166,587 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+c" || msg.String() == "q" {
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.state == statePalette {
+			return m.updatePalette(msg)
+		}
+		if m.state == stateConfirm {
+			return m.updateConfirm(msg)
+		}
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+		if msg.String() == "q" {
 			return m, tea.Quit
 		}
 
+		switch msg.String() {
+		case "/":
+			m.filtering = true
+			return m, nil
+		case "tab":
+			// Back to the palette
+			m.state = statePalette
+			m.selectedService = ""
+			m.environmentKeys = nil
+			m.environmentCursor = 0
+			m.resetFilter()
+			return m, nil
+		case "up", "k":
+			m.moveCursor(-1)
+			return m, nil
+		case "down", "j":
+			m.moveCursor(1)
+			return m, nil
+		}
+
 		switch m.state {
 		case stateSelectService:
-			// Service selection logic (remains the same)
-			switch msg.String() {
-			case "up", "k":
-				if len(m.serviceKeys) > 0 && m.serviceCursor > 0 {
-					m.serviceCursor--
-				}
-			case "down", "j":
-				if len(m.serviceKeys) > 0 && m.serviceCursor < len(m.serviceKeys)-1 {
-					m.serviceCursor++
-				}
-			case "enter":
-				if len(m.serviceKeys) > 0 && m.serviceCursor >= 0 && m.serviceCursor < len(m.serviceKeys) {
-					m.selectedService = m.serviceKeys[m.serviceCursor]
-					envKeys := []string{}
-					if serviceConf, ok := m.cfg.Services[m.selectedService]; ok && serviceConf.Environments != nil {
-						envKeys = make([]string, 0, len(serviceConf.Environments))
-						for k := range serviceConf.Environments {
-							envKeys = append(envKeys, k)
-						}
-						sort.Strings(envKeys)
-					}
-					m.environmentKeys = envKeys
-					m.environmentCursor = 0
-					m.state = stateSelectEnvironment
-				}
+			if msg.String() == "enter" {
+				m.selectService()
 			}
 
 		case stateSelectEnvironment:
 			// Environment selection logic
 			switch msg.String() {
-			case "up", "k":
-				if len(m.environmentKeys) > 0 && m.environmentCursor > 0 {
-					m.environmentCursor--
-				}
-			case "down", "j":
-				if len(m.environmentKeys) > 0 && m.environmentCursor < len(m.environmentKeys)-1 {
-					m.environmentCursor++
-				}
 			case "enter":
 				// --- Handle environment selection ---
-				if len(m.environmentKeys) > 0 && m.environmentCursor >= 0 && m.environmentCursor < len(m.environmentKeys) {
-					selectedEnv := m.environmentKeys[m.environmentCursor]
-
-					// Safely retrieve the environment configuration
-					var envConf config.EnvironmentConfig
-					var envOk bool
-					if serviceConf, serviceOk := m.cfg.Services[m.selectedService]; serviceOk {
-						envConf, envOk = serviceConf.Environments[selectedEnv]
-					}
-					if !envOk {
-						m.finalError = fmt.Errorf("internal error: could not find config for service '%s', environment '%s'", m.selectedService, selectedEnv)
-						return m, tea.Quit
-					}
-
-					// --- Conditional URL Generation ---
-					var serviceURL string
-					var genErr error // Only for generic case
-
-					if m.selectedService == "cloudrun" {
-						// Specific handling for Cloud Run
-						projectID := envConf.ProjectID
-						region := envConf.Region
-						if projectID == "" || region == "" {
-							m.finalError = fmt.Errorf("project_id or region not defined in config for service '%s', environment '%s'", m.selectedService, selectedEnv)
-							return m, tea.Quit
-						}
-						serviceURL = url.GenerateCloudRunURL(projectID, region)
-
-					} else if m.selectedService == "gke" {
-						// Specific handling for GKE
-						projectID := envConf.ProjectID
-						cluster := envConf.Cluster
-						if projectID == "" {
-							m.finalError = fmt.Errorf("project_id not defined in config for service '%s', environment '%s'", m.selectedService, selectedEnv)
-							return m, tea.Quit
-						}
-						serviceURL = url.GenerateGKEURL(projectID, cluster)
-
-					} else {
-						// Generic handling for other services
-						serviceURL, genErr = url.GenerateServiceURL(m.selectedService, envConf)
-						if genErr != nil {
-							m.finalError = fmt.Errorf("failed to generate URL: %w", genErr)
-							return m, tea.Quit // Quit on generation error
-						}
-					}
-
-					// --- Attempt to Open URL and Quit ---
-					m.finalURL = serviceURL // Store the URL
-
-					openErr := url.OpenURL(serviceURL) // Attempt to open
-					if openErr != nil {
-						m.finalError = fmt.Errorf("failed to open URL in browser: %w", openErr) // Store open error
-					}
-
-					return m, tea.Quit // Quit after attempting generation and opening
+				if len(m.selected) > 0 {
+					return m.launchSelection(false)
+				}
+				if env, ok := m.current(); ok {
+					return m.launchSelected(env, false)
+				}
+			case "y":
+				// --- Yank the highlighted environment's URL instead of opening it ---
+				if len(m.selected) > 0 {
+					return m.launchSelection(true)
+				}
+				if env, ok := m.current(); ok {
+					return m.launchSelected(env, true)
 				}
+			case " ":
+				m.toggleCurrent()
 			case "esc", "backspace":
+				if m.filter != "" {
+					m.resetFilter()
+					return m, nil
+				}
 				m.state = stateSelectService
 				m.selectedService = ""
 				m.environmentKeys = nil
 				m.environmentCursor = 0
+				m.refilter()
+			}
+		}
+	}
+	return m, nil
+}
+
+// updateFilter handles keys while a filter is being typed. Printable keys
+// extend the filter, Enter selects the highlighted item and Esc clears the
+// filter.
+func (m Model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.resetFilter()
+	case tea.KeyEnter:
+		m.filtering = false
+		if m.state == stateSelectService {
+			m.selectService()
+		} else if len(m.selected) > 0 {
+			return m.launchSelection(false)
+		} else if env, ok := m.current(); ok {
+			return m.launchSelected(env, false)
+		}
+	case tea.KeyBackspace:
+		if m.filter == "" {
+			m.filtering = false
+			return m, nil
+		}
+		runes := []rune(m.filter)
+		m.filter = string(runes[:len(runes)-1])
+		m.refilter()
+	case tea.KeyUp:
+		m.moveCursor(-1)
+	case tea.KeyDown:
+		m.moveCursor(1)
+	case tea.KeySpace:
+		m.toggleCurrent()
+	case tea.KeyRunes:
+		m.filter += string(msg.Runes)
+		m.refilter()
+	}
+	return m, nil
+}
+
+// moveCursor moves the cursor of the current list by delta within the
+// visible items.
+func (m *Model) moveCursor(delta int) {
+	cursor := m.cursor()
+	if next := *cursor + delta; next >= 0 && next < len(m.matches) {
+		*cursor = next
+	}
+}
+
+// current returns the key of the highlighted item of the current list.
+func (m Model) current() (string, bool) {
+	cursor := *m.cursor()
+	if cursor < 0 || cursor >= len(m.matches) {
+		return "", false
+	}
+	index := m.matches[cursor].Index
+	if m.state == stateSelectService {
+		return m.serviceKeys[index], true
+	}
+	return m.environmentKeys[index], true
+}
+
+// currentRef returns the target of the highlighted palette item or
+// environment.
+func (m Model) currentRef() (config.TargetRef, bool) {
+	cursor := *m.cursor()
+	if cursor < 0 || cursor >= len(m.matches) {
+		return config.TargetRef{}, false
+	}
+	return m.refAt(m.matches[cursor].Index)
+}
+
+// refAt returns the target of an item of the current list; service types
+// are not targets.
+func (m Model) refAt(index int) (config.TargetRef, bool) {
+	switch m.state {
+	case statePalette:
+		item := m.paletteItems[index]
+		return config.TargetRef{Service: item.service, Environment: item.environment, Bookmark: item.bookmark}, true
+	case stateSelectEnvironment:
+		return config.TargetRef{Service: m.selectedService, Environment: m.environmentKeys[index]}, true
+	}
+	return config.TargetRef{}, false
+}
+
+// toggleCurrent adds the highlighted target to the selection, or removes
+// it if it is already selected.
+func (m *Model) toggleCurrent() {
+	ref, ok := m.currentRef()
+	if !ok {
+		return
+	}
+	for i, r := range m.selected {
+		if r == ref {
+			m.selected = append(m.selected[:i:i], m.selected[i+1:]...)
+			return
+		}
+	}
+	m.selected = append(m.selected, ref)
+}
+
+// isSelected reports whether a target is in the selection.
+func (m Model) isSelected(ref config.TargetRef) bool {
+	for _, r := range m.selected {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// selectService moves on to the environments of the highlighted service.
+func (m *Model) selectService() {
+	service, ok := m.current()
+	if !ok {
+		return
+	}
+	m.selectedService = service
+	envKeys := []string{}
+	if serviceConf, ok := m.cfg.Services[m.selectedService]; ok && serviceConf.Environments != nil {
+		envKeys = make([]string, 0, len(serviceConf.Environments))
+		for k, env := range serviceConf.Environments {
+			if m.environmentFilter.Matches(env) {
+				envKeys = append(envKeys, k)
+			}
+		}
+		sort.Strings(envKeys)
+		history.Rank(envKeys, m.environmentScores())
+	}
+	m.groupEnvironments(envKeys)
+	m.environmentKeys = envKeys
+	m.environmentCursor = 0
+	m.state = stateSelectEnvironment
+	m.resetFilter()
+}
+
+// groupEnvironments groups the environments of the selected service by the
+// group label, if any of them has it, ordering the groups by name with the
+// environments without the label last.
+func (m *Model) groupEnvironments(envKeys []string) {
+	m.groups = nil
+	label := m.cfg.GroupLabel()
+	groups := map[string]string{}
+	for _, k := range envKeys {
+		if group := m.cfg.Services[m.selectedService].Environments[k].Labels[label]; group != "" {
+			groups[k] = group
+		}
+	}
+	if len(groups) == 0 {
+		return
+	}
+	for _, k := range envKeys {
+		if groups[k] == "" {
+			groups[k] = ungrouped
+		}
+	}
+	sort.SliceStable(envKeys, func(i, j int) bool {
+		gi, gj := groups[envKeys[i]], groups[envKeys[j]]
+		if (gi == ungrouped) != (gj == ungrouped) {
+			return gj == ungrouped
+		}
+		return gi < gj
+	})
+	m.groups = groups
+}
+
+// environmentScores returns the frecency of the selected service's
+// environments, keyed by environment.
+func (m Model) environmentScores() map[string]int {
+	scores := map[string]int{}
+	for key, score := range m.targetScores {
+		if service, environment, _ := strings.Cut(key, "/"); service == m.selectedService {
+			scores[environment] = score
+		}
+	}
+	return scores
+}
+
+// launchSelected resolves the selected service in the given environment and
+// opens it, unless yanking or opening is disabled, then quits.
+func (m Model) launchSelected(selectedEnv string, yank bool) (tea.Model, tea.Cmd) {
+	target, err := m.launcher.Resolve(m.selectedService, selectedEnv, m.launchOptions)
+	return m.launchTarget(target, err, yank)
+}
+
+// launchBookmark resolves a bookmark and opens it like launchSelected.
+func (m Model) launchBookmark(name string, yank bool) (tea.Model, tea.Cmd) {
+	target, err := m.launcher.ResolveBookmark(name, m.launchOptions)
+	return m.launchTarget(target, err, yank)
+}
+
+// launchSelection resolves every selected target and, once any protected
+// ones are confirmed, quits, leaving the caller to open them at a measured
+// pace. Nothing is launched if any target fails to resolve.
+func (m Model) launchSelection(yank bool) (tea.Model, tea.Cmd) {
+	targets := make([]launch.Target, 0, len(m.selected))
+	for _, ref := range m.selected {
+		target, err := m.launcher.ResolveRef(ref, m.launchOptions)
+		if err != nil {
+			m.finalError = fmt.Errorf("cannot launch %s: %w", ref, err)
+			return m, tea.Quit
+		}
+		targets = append(targets, target)
+	}
+	targets, err := m.readOnlyTargets(targets)
+	if err != nil {
+		m.finalError = err
+		return m, tea.Quit
+	}
+	if yank || m.noOpen {
+		m.yanked = yank
+		return m.finish(targets)
+	}
+	return m.openTargets(targets)
+}
+
+// launchTarget opens a resolved target, after confirmation if it is
+// protected, unless yanking or opening is disabled, then quits.
+func (m Model) launchTarget(target launch.Target, err error, yank bool) (tea.Model, tea.Cmd) {
+	if err != nil {
+		m.finalError = err
+		return m, tea.Quit // Quit on resolution error
+	}
+	targets, err := m.readOnlyTargets([]launch.Target{target})
+	if err != nil {
+		m.finalError = err
+		return m, tea.Quit
+	}
+	if yank {
+		// The URL is copied once the TUI has released the terminal
+		m.yanked = true
+		return m.finish(targets)
+	}
+	if m.noOpen {
+		return m.finish(targets)
+	}
+	return m.openTargets(targets)
+}
+
+// readOnlyTargets switches protected targets to their authuser when
+// opening read-only.
+func (m Model) readOnlyTargets(targets []launch.Target) ([]launch.Target, error) {
+	if !m.readOnly {
+		return targets, nil
+	}
+	for i, target := range targets {
+		if target.Protected && target.Config.Authuser != "" {
+			readOnly, err := m.launcher.ReadOnly(target)
+			if err != nil {
+				return nil, err
 			}
+			readOnly.Protected = false
+			targets[i] = readOnly
 		}
 	}
+	return targets, nil
+}
+
+// openTargets asks for the first protected target left to be confirmed,
+// if any. Once all are confirmed a single target is opened and several are
+// left to the caller to open at a measured pace.
+func (m Model) openTargets(targets []launch.Target) (tea.Model, tea.Cmd) {
+	if !m.noConfirm {
+		for _, target := range targets {
+			if target.Protected {
+				if m.state != stateConfirm {
+					m.returnState = m.state
+				}
+				m.state = stateConfirm
+				m.pending = targets
+				m.confirmInput = ""
+				return m, nil
+			}
+		}
+	}
+	if len(targets) > 1 {
+		return m.finish(targets)
+	}
+
+	// --- Attempt to Open URL and Quit ---
+	target := targets[0]
+	m.finalTarget = target
+	m.finalTargets = targets
+	m.finalURL = target.URL            // Store the URL
+	openErr := m.launcher.Open(target) // Attempt to open
+	if openErr != nil {
+		m.finalError = fmt.Errorf("failed to open URL in browser: %w", openErr) // Store open error
+	}
+
+	return m, tea.Quit // Quit after attempting generation and opening
+}
+
+// finish records the resolved targets for the caller and quits.
+func (m Model) finish(targets []launch.Target) (tea.Model, tea.Cmd) {
+	m.finalTargets = targets
+	m.finalTarget = targets[0]
+	m.finalURL = targets[0].URL
+	return m, tea.Quit
+}
+
+// confirming returns the protected target being confirmed.
+func (m Model) confirming() launch.Target {
+	for _, target := range m.pending {
+		if target.Protected {
+			return target
+		}
+	}
+	return launch.Target{}
+}
+
+// updateConfirm handles keys while a protected environment is confirmed:
+// its name and Enter opens it, Enter alone opens it read-only if it has an
+// authuser, and Esc cancels.
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = m.returnState
+		m.pending = nil
+		m.confirmInput = ""
+		m.confirmError = ""
+	case tea.KeyEnter:
+		target := m.confirming()
+		env, input := target.Environment, strings.TrimSpace(m.confirmInput)
+		m.confirmInput = ""
+		if input != env && (input != "" || target.Config.Authuser == "") {
+			m.confirmError = fmt.Sprintf("'%s' does not match '%s'", input, env)
+			return m, nil
+		}
+		m.confirmError = ""
+		// The answer applies to every pending target in the environment
+		for i, t := range m.pending {
+			if !t.Protected || t.Environment != env {
+				continue
+			}
+			if input == "" {
+				readOnly, err := m.launcher.ReadOnly(t)
+				if err != nil {
+					m.finalError = err
+					return m, tea.Quit
+				}
+				t = readOnly
+			}
+			t.Protected = false
+			m.pending[i] = t
+		}
+		return m.openTargets(m.pending)
+	case tea.KeyBackspace:
+		if runes := []rune(m.confirmInput); len(runes) > 0 {
+			m.confirmInput = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.confirmInput += string(msg.Runes)
+	}
 	return m, nil
 }
 
+// protectedAt reports whether an item of the current list is protected.
+func (m Model) protectedAt(index int) bool {
+	ref, ok := m.refAt(index)
+	if !ok {
+		return false
+	}
+	if ref.Bookmark != "" {
+		env := m.cfg.Bookmarks[ref.Bookmark].Environment
+		return env != "" && m.cfg.IsProtected("", env)
+	}
+	return m.cfg.IsProtected(ref.Service, ref.Environment)
+}
+
 func (m Model) View() string {
 	var sb strings.Builder
 	switch m.state {
+	case statePalette:
+		sb.WriteString("Launch (type to filter, ↑/↓ to move, Space to select several, Enter to open, Ctrl+Y to copy URL, Tab for step-by-step, Esc to quit):\n\n")
+		if len(m.paletteItems) == 0 {
+			sb.WriteString("No environments defined in the configuration file.\n")
+		} else {
+			m.writeList(&sb, len(m.paletteItems))
+		}
 	case stateSelectService:
-		sb.WriteString("Select a Service Type (Use ↑/↓ arrows, Enter to select, q to quit):\n\n")
+		sb.WriteString("Select a Service Type (Use ↑/↓ arrows, / to filter, Enter to select, Tab for the palette, q to quit):\n\n")
 		if len(m.serviceKeys) == 0 {
 			sb.WriteString("No services defined in the configuration file.\n")
 		} else {
-			for i, serviceKey := range m.serviceKeys {
-				cursorIndicator := "  "
-				if m.serviceCursor == i {
-					cursorIndicator = "> "
-				}
-				sb.WriteString(cursorIndicator)
-				sb.WriteString(serviceKey)
-				sb.WriteString("\n")
-			}
+			m.writeList(&sb, len(m.serviceKeys))
 		}
 	case stateSelectEnvironment:
-		sb.WriteString(fmt.Sprintf("Select Environment for '%s' (Use ↑/↓, Enter to open, Esc/Backspace back, q to quit):\n\n", m.selectedService))
+		sb.WriteString(fmt.Sprintf("Select Environment for '%s' (Use ↑/↓, / to filter, Space to select several, Enter to open, y to copy URL, Esc/Backspace back, Tab for the palette, q to quit):\n\n", m.selectedService))
 		if len(m.environmentKeys) == 0 {
 			sb.WriteString(fmt.Sprintf("No environments defined for service '%s'.\n", m.selectedService))
 		} else {
-			for i, envKey := range m.environmentKeys {
-				cursorIndicator := "  "
-				if m.environmentCursor == i {
-					cursorIndicator = "> "
-				}
-				sb.WriteString(cursorIndicator)
-				sb.WriteString(envKey)
-				sb.WriteString("\n")
-			}
+			m.writeList(&sb, len(m.environmentKeys))
+		}
+	case stateConfirm:
+		target := m.confirming()
+		what := "bookmark " + target.Bookmark
+		if target.Bookmark == "" {
+			what = target.Service
+		}
+		sb.WriteString(bannerStyle.Render(fmt.Sprintf(" PROTECTED: environment '%s' ", target.Environment)))
+		sb.WriteString(fmt.Sprintf("\n\nOpening %s in '%s' needs confirmation.\n", what, target.Environment))
+		if authuser := target.Config.Authuser; authuser != "" {
+			sb.WriteString(fmt.Sprintf("Type '%s' and press Enter to open it, or just press Enter to open it read-only as %s (Esc to cancel):\n\n", target.Environment, authuser))
+		} else {
+			sb.WriteString(fmt.Sprintf("Type '%s' and press Enter to open it (Esc to cancel):\n\n", target.Environment))
+		}
+		sb.WriteString("> " + m.confirmInput + "_\n")
+		if m.confirmError != "" {
+			sb.WriteString("\n" + productionStyle.Render(m.confirmError) + "\n")
 		}
 	default:
 		sb.WriteString("Unknown application state.\n")
 	}
-	sb.WriteString("\n(Press 'q' to quit)\n")
+	if m.state != stateConfirm {
+		if cursor := *m.cursor(); cursor >= 0 && cursor < len(m.matches) && m.protectedAt(m.matches[cursor].Index) {
+			sb.WriteString("\n" + bannerStyle.Render(" PROTECTED ") + " Opening this environment asks for confirmation\n")
+		}
+	}
+	if len(m.selected) > 0 {
+		sb.WriteString(fmt.Sprintf("\n%d selected: Enter opens them all, Space on a selected item removes it\n", len(m.selected)))
+	}
+	if m.state == statePalette {
+		sb.WriteString("\n(Press Esc or Ctrl+C to quit)\n")
+	} else if m.state == stateConfirm {
+		sb.WriteString("\n(Press Esc to cancel or Ctrl+C to quit)\n")
+	} else {
+		sb.WriteString("\n(Press 'q' to quit)\n")
+	}
 	return sb.String()
 }
-func (m Model) GetFinalURL() string  { return m.finalURL }
-func (m Model) GetFinalError() error { return m.finalError }
+
+// writeList writes the filter line, if any, and the visible items of the
+// current list out of total, scrolled to keep the cursor on screen.
+func (m Model) writeList(sb *strings.Builder, total int) {
+	if m.state == statePalette || m.filtering || m.filter != "" {
+		cursor := ""
+		if m.state == statePalette || m.filtering {
+			cursor = "_"
+		}
+		sb.WriteString(fmt.Sprintf("Filter: %s%s  (%d/%d)\n\n", m.filter, cursor, len(m.matches), total))
+	}
+	if len(m.matches) == 0 {
+		sb.WriteString("No matches.\n")
+	}
+	rows, cursorRow := m.listRows()
+	// Leave room for the title, filter and footer lines
+	if visible := m.height - 8; m.height > 0 && len(rows) > visible && visible > 0 {
+		start := cursorRow - visible/2
+		start = max(0, min(start, len(rows)-visible))
+		rows = rows[start : start+visible]
+	}
+	for _, row := range rows {
+		sb.WriteString(row)
+		sb.WriteString("\n")
+	}
+}
+
+// listRows renders the visible items of the current list, with section
+// headers in the unfiltered palette and environment groups, and returns the
+// row of the cursor.
+func (m Model) listRows() ([]string, int) {
+	var rows []string
+	cursorRow := 0
+	cursor := *m.cursor()
+	section := ""
+	for i, r := range m.matches {
+		if m.filter == "" {
+			if s := m.section(r.Index); s != "" && s != section {
+				if section != "" {
+					rows = append(rows, "")
+				}
+				rows = append(rows, detailStyle.Render(s))
+				section = s
+			}
+		}
+		cursorIndicator := "  "
+		if cursor == i {
+			cursorIndicator = "> "
+			cursorRow = len(rows)
+		}
+		mark := "  "
+		if ref, ok := m.refAt(r.Index); ok && m.isSelected(ref) {
+			mark = matchStyle.Render("✓ ")
+		}
+		rows = append(rows, cursorIndicator+mark+m.renderMatch(r))
+	}
+	return rows, cursorRow
+}
+
+// section returns the header an item of the current list is listed under.
+func (m Model) section(index int) string {
+	switch m.state {
+	case statePalette:
+		return m.paletteItems[index].section
+	case stateSelectEnvironment:
+		return m.groups[m.environmentKeys[index]]
+	}
+	return ""
+}
+
+func (m Model) GetFinalURL() string              { return m.finalURL }
+func (m Model) GetFinalError() error             { return m.finalError }
+func (m Model) GetFinalTarget() launch.Target    { return m.finalTarget }
+func (m Model) GetFinalTargets() []launch.Target { return m.finalTargets }
+func (m Model) WasYanked() bool                  { return m.yanked }
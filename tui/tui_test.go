@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/tom-gray/gcp-launch/config"
+	"github.com/tom-gray/gcp-launch/launch"
+)
+
+const (
+	bigqueryProd    = "https://console.cloud.google.com/bigquery?project=prod-project"
+	bigqueryProdRO  = bigqueryProd + "&authuser=ro%40example.com"
+	bigqueryStaging = "https://console.cloud.google.com/bigquery?project=staging-project"
+	bigqueryDev     = "https://console.cloud.google.com/bigquery?project=dev-project"
+	loggingProd     = "https://console.cloud.google.com/logs/query?project=prod-project"
+)
+
+func testConfig() *config.Config {
+	environments := func() map[string]config.EnvironmentConfig {
+		return map[string]config.EnvironmentConfig{
+			"prod":    {ProjectID: "prod-project", Protected: true, Authuser: "ro@example.com"},
+			"staging": {ProjectID: "staging-project", Protected: true},
+			"dev":     {ProjectID: "dev-project"},
+		}
+	}
+	return &config.Config{
+		// A single target is opened by the model itself; "true" stands in
+		// for the browser.
+		Browser: "true",
+		Services: map[string]config.ServiceTypeConfig{
+			"bigquery": {Environments: environments()},
+			"logging":  {Environments: environments()},
+		},
+	}
+}
+
+// keyMsg returns the message for a named key, or for typing the text.
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// press sends the keys to the model in turn and reports whether it quit.
+func press(m Model, keys ...string) (Model, bool) {
+	quit := false
+	for _, key := range keys {
+		next, cmd := m.Update(keyMsg(key))
+		m = next.(Model)
+		quit = quitWith(cmd)
+	}
+	return m, quit
+}
+
+// quitWith runs cmd and reports whether it quit the program.
+func quitWith(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func urls(targets []launch.Target) []string {
+	var result []string
+	for _, target := range targets {
+		result = append(result, target.URL)
+	}
+	return result
+}
+
+func TestConfirmProtected(t *testing.T) {
+	tests := []struct {
+		name        string
+		refs        []string
+		noConfirm   bool
+		readOnly    bool
+		keys        []string
+		expected    []string
+		expectError string
+	}{
+		{name: "unprotected", refs: []string{"bigquery/dev"}, expected: []string{bigqueryDev}},
+		{name: "typed name matches", refs: []string{"bigquery/prod"}, keys: []string{"prod", "enter"}, expected: []string{bigqueryProd}},
+		{name: "typed name edited", refs: []string{"bigquery/prod"}, keys: []string{"prodd", "backspace", "enter"}, expected: []string{bigqueryProd}},
+		{name: "typed name does not match", refs: []string{"bigquery/prod"}, keys: []string{"prd", "enter"}, expectError: "'prd' does not match 'prod'"},
+		{name: "enter with authuser", refs: []string{"bigquery/prod"}, keys: []string{"enter"}, expected: []string{bigqueryProdRO}},
+		{name: "enter without authuser", refs: []string{"bigquery/staging"}, keys: []string{"enter"}, expectError: "'' does not match 'staging'"},
+		{name: "without confirmation", refs: []string{"bigquery/prod", "bigquery/staging"}, noConfirm: true, expected: []string{bigqueryProd, bigqueryStaging}},
+		{name: "read-only", refs: []string{"bigquery/prod"}, readOnly: true, expected: []string{bigqueryProdRO}},
+		{name: "read-only asks without authuser", refs: []string{"bigquery/prod", "bigquery/staging"}, readOnly: true, keys: []string{"staging", "enter"}, expected: []string{bigqueryProdRO, bigqueryStaging}},
+		{
+			name:     "one confirmation per environment",
+			refs:     []string{"bigquery/prod", "logging/prod", "bigquery/staging", "bigquery/dev"},
+			keys:     []string{"prod", "enter", "staging", "enter"},
+			expected: []string{bigqueryProd, loggingProd, bigqueryStaging, bigqueryDev},
+		},
+		{name: "second environment not confirmed", refs: []string{"bigquery/prod", "bigquery/staging"}, keys: []string{"prod", "enter", "prod", "enter"}, expectError: "'prod' does not match 'staging'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(testConfig())
+			if tt.noConfirm {
+				m = m.WithoutConfirmation()
+			}
+			if tt.readOnly {
+				m = m.WithReadOnly()
+			}
+			for _, ref := range tt.refs {
+				service, environment, _ := strings.Cut(ref, "/")
+				m.selected = append(m.selected, config.TargetRef{Service: service, Environment: environment})
+			}
+			next, cmd := m.launchSelection(false)
+			m = next.(Model)
+			quit := quitWith(cmd)
+			if len(tt.keys) > 0 {
+				m, quit = press(m, tt.keys...)
+			}
+			if tt.expectError != "" {
+				if quit || m.state != stateConfirm || m.confirmError != tt.expectError {
+					t.Errorf("quit = %v, state = %v, confirmError = %q; want to keep confirming with %q", quit, m.state, m.confirmError, tt.expectError)
+				}
+				return
+			}
+			if !quit || m.GetFinalError() != nil {
+				t.Fatalf("quit = %v, error = %v, state = %v; want to quit without error", quit, m.GetFinalError(), m.state)
+			}
+			if got := urls(m.GetFinalTargets()); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("final URLs = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfirmFromEnvironmentList(t *testing.T) {
+	m := NewModel(testConfig())
+	// Tab to the service list, pick bigquery and filter its environments
+	m, quit := press(m, "tab", "enter", "/", "prod", "enter")
+	if quit || m.state != stateConfirm || m.confirming().Environment != "prod" {
+		t.Fatalf("quit = %v, state = %v; want to confirm prod", quit, m.state)
+	}
+	if view := m.View(); !strings.Contains(view, "PROTECTED") || !strings.Contains(view, "ro@example.com") {
+		t.Errorf("confirmation view = %q, want the protected banner and the authuser", view)
+	}
+
+	// Esc returns to the environment list without opening anything
+	m, quit = press(m, "pro", "esc")
+	if quit || m.state != stateSelectEnvironment || m.pending != nil || m.confirmInput != "" {
+		t.Errorf("after Esc quit = %v, state = %v, pending = %v, input = %q; want the environment list", quit, m.state, m.pending, m.confirmInput)
+	}
+
+	// A second confirmation returns to the same list
+	m, _ = press(m, "/", "prod", "enter", "esc")
+	if m.state != stateSelectEnvironment {
+		t.Errorf("after a second Esc state = %v, want the environment list", m.state)
+	}
+}
+
+func TestFilterEnterOpensSelection(t *testing.T) {
+	m := NewModel(testConfig()).WithoutConfirmation()
+	// Select dev and prod, then filter down to staging and press Enter
+	m, _ = press(m, "tab", "enter", " ", "j", " ", "/", "stag")
+	m, quit := press(m, "enter")
+	if !quit {
+		t.Fatalf("Enter while filtering did not quit, state = %v", m.state)
+	}
+	if got, want := urls(m.GetFinalTargets()), []string{bigqueryDev, bigqueryProd}; !reflect.DeepEqual(got, want) {
+		t.Errorf("final URLs = %v, want the selection %v", got, want)
+	}
+}
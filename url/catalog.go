@@ -0,0 +1,208 @@
+package url
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tom-gray/gcp-launch/config"
+)
+
+// CatalogEntry describes a built-in console destination.
+type CatalogEntry struct {
+	Name        string
+	Description string
+	// Template is a Go text/template rendered against the EnvironmentConfig.
+	Template string
+	// Required lists the configuration keys (e.g. "project_id", "region")
+	// that must be set for the template to produce a usable URL. Keys the
+	// URL only uses when set, such as an instance, are not required.
+	Required []string
+	// ContextParams lists the configuration keys the optional context
+	// argument on the command line may set, in order of preference.
+	ContextParams []string
+
+	// build, when set, generates the URL in code instead of via Template.
+	build func(config.EnvironmentConfig) (string, error)
+}
+
+// catalog is the registry of console pages gcp-launch can open without a
+// url_template in the configuration. Keys are the service type names used in
+// the configuration file and on the command line.
+var catalog = map[string]CatalogEntry{}
+
+func init() {
+	for _, e := range []CatalogEntry{
+		// Original service types
+		{Name: "logging", Description: "Cloud Logging Logs Explorer", Required: []string{"project_id"}, Template: consoleBaseURL + "/logs/query?project={{.ProjectID}}", build: buildLoggingURL},
+		{Name: "cloudrun", Description: "Cloud Run services", Required: []string{"project_id", "region"}, Template: consoleBaseURL + "/run?project={{.ProjectID}}&region={{.Region}}", ContextParams: []string{"region", "service"}, build: buildCloudRunURL},
+		{Name: "gke", Description: "GKE workloads, clusters and namespaces", Required: []string{"project_id"}, Template: consoleBaseURL + "/kubernetes/workload/overview?project={{.ProjectID}}", ContextParams: []string{"location", "cluster"}, build: buildGKEURL},
+		{Name: "spanner", Description: "Cloud Spanner instances", Required: []string{"project_id"}, ContextParams: []string{"instance"},
+			Template: consoleBaseURL + "/spanner{{if .Instance}}/instances/{{.Instance}}/details/databases{{end}}?project={{.ProjectID}}"},
+
+		// Compute
+		{Name: "compute", Description: "Compute Engine VM instances", Required: []string{"project_id"}, Template: consoleBaseURL + "/compute/instances?project={{.ProjectID}}"},
+		{Name: "instance-groups", Description: "Compute Engine instance groups", Required: []string{"project_id"}, Template: consoleBaseURL + "/compute/instanceGroups/list?project={{.ProjectID}}"},
+		{Name: "disks", Description: "Compute Engine disks", Required: []string{"project_id"}, Template: consoleBaseURL + "/compute/disks?project={{.ProjectID}}"},
+		{Name: "cloudrun-jobs", Description: "Cloud Run jobs", Required: []string{"project_id"}, Template: consoleBaseURL + "/run/jobs?project={{.ProjectID}}"},
+		{Name: "gke-clusters", Description: "GKE clusters", Required: []string{"project_id"}, Template: consoleBaseURL + "/kubernetes/list/overview?project={{.ProjectID}}"},
+		{Name: "gke-services", Description: "GKE services and ingress", Required: []string{"project_id"}, Template: consoleBaseURL + "/kubernetes/discovery?project={{.ProjectID}}"},
+		{Name: "cloud-functions", Description: "Cloud Functions", Required: []string{"project_id"}, Template: consoleBaseURL + "/functions/list?project={{.ProjectID}}"},
+		{Name: "app-engine", Description: "App Engine dashboard", Required: []string{"project_id"}, Template: consoleBaseURL + "/appengine?project={{.ProjectID}}"},
+
+		// Data
+		{Name: "bigquery", Description: "BigQuery studio", Required: []string{"project_id"}, Template: consoleBaseURL + "/bigquery?project={{.ProjectID}}"},
+		{Name: "pubsub", Description: "Pub/Sub topics", Required: []string{"project_id"}, Template: consoleBaseURL + "/cloudpubsub/topic/list?project={{.ProjectID}}"},
+		{Name: "pubsub-subscriptions", Description: "Pub/Sub subscriptions", Required: []string{"project_id"}, Template: consoleBaseURL + "/cloudpubsub/subscription/list?project={{.ProjectID}}"},
+		{Name: "cloudsql", Description: "Cloud SQL instances", Required: []string{"project_id"}, ContextParams: []string{"instance"},
+			Template: consoleBaseURL + "/sql/instances{{if .Instance}}/{{.Instance}}/overview{{end}}?project={{.ProjectID}}"},
+		{Name: "memorystore", Description: "Memorystore for Redis", Required: []string{"project_id"}, Template: consoleBaseURL + "/memorystore/redis/instances?project={{.ProjectID}}"},
+		{Name: "memorystore-memcached", Description: "Memorystore for Memcached", Required: []string{"project_id"}, Template: consoleBaseURL + "/memorystore/memcached/instances?project={{.ProjectID}}"},
+		{Name: "gcs", Description: "Cloud Storage buckets", Required: []string{"project_id"}, Template: consoleBaseURL + "/storage/browser?project={{.ProjectID}}"},
+		{Name: "firestore", Description: "Firestore databases", Required: []string{"project_id"}, Template: consoleBaseURL + "/firestore/databases?project={{.ProjectID}}"},
+		{Name: "datastore", Description: "Datastore entities", Required: []string{"project_id"}, Template: consoleBaseURL + "/datastore/entities?project={{.ProjectID}}"},
+		{Name: "bigtable", Description: "Bigtable instances", Required: []string{"project_id"}, ContextParams: []string{"instance"},
+			Template: consoleBaseURL + "/bigtable/instances{{if .Instance}}/{{.Instance}}/overview{{end}}?project={{.ProjectID}}"},
+		{Name: "dataflow", Description: "Dataflow jobs", Required: []string{"project_id"}, Template: consoleBaseURL + "/dataflow/jobs?project={{.ProjectID}}"},
+		{Name: "dataproc", Description: "Dataproc clusters", Required: []string{"project_id"}, Template: consoleBaseURL + "/dataproc/clusters?project={{.ProjectID}}"},
+		{Name: "composer", Description: "Cloud Composer environments", Required: []string{"project_id"}, Template: consoleBaseURL + "/composer/environments?project={{.ProjectID}}"},
+
+		// Security and identity
+		{Name: "iam", Description: "IAM principals", Required: []string{"project_id"}, Template: consoleBaseURL + "/iam-admin/iam?project={{.ProjectID}}"},
+		{Name: "service-accounts", Description: "IAM service accounts", Required: []string{"project_id"}, Template: consoleBaseURL + "/iam-admin/serviceaccounts?project={{.ProjectID}}"},
+		{Name: "iam-roles", Description: "IAM roles", Required: []string{"project_id"}, Template: consoleBaseURL + "/iam-admin/roles?project={{.ProjectID}}"},
+		{Name: "audit-logs", Description: "Audit log configuration", Required: []string{"project_id"}, Template: consoleBaseURL + "/iam-admin/audit?project={{.ProjectID}}"},
+		{Name: "secret-manager", Description: "Secret Manager", Required: []string{"project_id"}, Template: consoleBaseURL + "/security/secret-manager?project={{.ProjectID}}"},
+		{Name: "kms", Description: "Cloud KMS key rings", Required: []string{"project_id"}, Template: consoleBaseURL + "/security/kms/keyrings?project={{.ProjectID}}"},
+		{Name: "security-command-center", Description: "Security Command Center", Required: []string{"project_id"}, Template: consoleBaseURL + "/security/command-center?project={{.ProjectID}}"},
+		{Name: "cloud-armor", Description: "Cloud Armor policies", Required: []string{"project_id"}, Template: consoleBaseURL + "/net-security/securitypolicies/list?project={{.ProjectID}}"},
+		{Name: "certificate-manager", Description: "Certificate Manager", Required: []string{"project_id"}, Template: consoleBaseURL + "/security/ccm/list/lbCertificates?project={{.ProjectID}}"},
+
+		// CI/CD
+		{Name: "cloudbuild", Description: "Cloud Build history", Required: []string{"project_id"}, Template: consoleBaseURL + "/cloud-build/builds?project={{.ProjectID}}"},
+		{Name: "cloudbuild-triggers", Description: "Cloud Build triggers", Required: []string{"project_id"}, Template: consoleBaseURL + "/cloud-build/triggers?project={{.ProjectID}}"},
+		{Name: "artifact-registry", Description: "Artifact Registry repositories", Required: []string{"project_id"}, Template: consoleBaseURL + "/artifacts?project={{.ProjectID}}"},
+		{Name: "cloud-deploy", Description: "Cloud Deploy delivery pipelines", Required: []string{"project_id"}, Template: consoleBaseURL + "/deploy/delivery-pipelines?project={{.ProjectID}}"},
+
+		// Operations
+		{Name: "monitoring", Description: "Cloud Monitoring overview", Required: []string{"project_id"}, Template: consoleBaseURL + "/monitoring?project={{.ProjectID}}"},
+		{Name: "monitoring-dashboards", Description: "Cloud Monitoring dashboards", Required: []string{"project_id"}, Template: consoleBaseURL + "/monitoring/dashboards?project={{.ProjectID}}"},
+		{Name: "metrics-explorer", Description: "Cloud Monitoring metrics explorer", Required: []string{"project_id"}, Template: consoleBaseURL + "/monitoring/metrics-explorer?project={{.ProjectID}}"},
+		{Name: "alerting", Description: "Cloud Monitoring alerting", Required: []string{"project_id"}, Template: consoleBaseURL + "/monitoring/alerting?project={{.ProjectID}}"},
+		{Name: "uptime-checks", Description: "Cloud Monitoring uptime checks", Required: []string{"project_id"}, Template: consoleBaseURL + "/monitoring/uptime?project={{.ProjectID}}"},
+		{Name: "error-reporting", Description: "Error Reporting", Required: []string{"project_id"}, Template: consoleBaseURL + "/errors?project={{.ProjectID}}"},
+		{Name: "trace", Description: "Cloud Trace explorer", Required: []string{"project_id"}, Template: consoleBaseURL + "/traces/list?project={{.ProjectID}}"},
+		{Name: "profiler", Description: "Cloud Profiler", Required: []string{"project_id"}, Template: consoleBaseURL + "/profiler?project={{.ProjectID}}"},
+
+		// Networking
+		{Name: "load-balancing", Description: "Load balancers", Required: []string{"project_id"}, Template: consoleBaseURL + "/net-services/loadbalancing/list/loadBalancers?project={{.ProjectID}}"},
+		{Name: "vpc", Description: "VPC networks", Required: []string{"project_id"}, Template: consoleBaseURL + "/networking/networks/list?project={{.ProjectID}}"},
+		{Name: "firewall", Description: "VPC firewall rules", Required: []string{"project_id"}, Template: consoleBaseURL + "/net-security/firewall-manager/firewall-policies/list?project={{.ProjectID}}"},
+		{Name: "cloud-nat", Description: "Cloud NAT gateways", Required: []string{"project_id"}, Template: consoleBaseURL + "/net-services/nat/list?project={{.ProjectID}}"},
+		{Name: "cloud-dns", Description: "Cloud DNS zones", Required: []string{"project_id"}, Template: consoleBaseURL + "/net-services/dns/zones?project={{.ProjectID}}"},
+		{Name: "api-gateway", Description: "API Gateway", Required: []string{"project_id"}, Template: consoleBaseURL + "/api-gateway?project={{.ProjectID}}"},
+
+		// Integration
+		{Name: "scheduler", Description: "Cloud Scheduler jobs", Required: []string{"project_id"}, Template: consoleBaseURL + "/cloudscheduler?project={{.ProjectID}}"},
+		{Name: "cloud-tasks", Description: "Cloud Tasks queues", Required: []string{"project_id"}, Template: consoleBaseURL + "/cloudtasks?project={{.ProjectID}}"},
+		{Name: "workflows", Description: "Workflows", Required: []string{"project_id"}, Template: consoleBaseURL + "/workflows?project={{.ProjectID}}"},
+		{Name: "eventarc", Description: "Eventarc triggers", Required: []string{"project_id"}, Template: consoleBaseURL + "/eventarc/triggers?project={{.ProjectID}}"},
+		{Name: "vertex-ai", Description: "Vertex AI dashboard", Required: []string{"project_id"}, Template: consoleBaseURL + "/vertex-ai?project={{.ProjectID}}"},
+
+		// Administration
+		{Name: "billing", Description: "Billing account linked to the project", Required: []string{"project_id"}, Template: consoleBaseURL + "/billing/linkedaccount?project={{.ProjectID}}"},
+		{Name: "quotas", Description: "IAM & Admin quotas", Required: []string{"project_id"}, Template: consoleBaseURL + "/iam-admin/quotas?project={{.ProjectID}}"},
+		{Name: "apis", Description: "APIs & Services dashboard", Required: []string{"project_id"}, Template: consoleBaseURL + "/apis/dashboard?project={{.ProjectID}}"},
+		{Name: "recommendations", Description: "Recommendation Hub", Required: []string{"project_id"}, Template: consoleBaseURL + "/active-assist/list/recommendations?project={{.ProjectID}}"},
+	} {
+		catalog[e.Name] = e
+	}
+}
+
+// LookupCatalog returns the built-in catalog entry for a service type.
+func LookupCatalog(serviceType string) (CatalogEntry, bool) {
+	e, ok := catalog[serviceType]
+	return e, ok
+}
+
+// Catalog returns every built-in catalog entry sorted by name.
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(catalog))
+	for _, e := range catalog {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// generate builds the URL for the entry. The caller has already checked the
+// required parameters.
+func (e CatalogEntry) generate(envConfig config.EnvironmentConfig) (string, error) {
+	if e.build != nil {
+		return e.build(envConfig)
+	}
+	return GenerateTemplateURL(e.Template, envConfig)
+}
+
+// buildCloudRunURL links to a specific Cloud Run service when one is
+// configured, and to the (optionally region-filtered) service list otherwise.
+func buildCloudRunURL(envConfig config.EnvironmentConfig) (string, error) {
+	if envConfig.Service != "" {
+		return GenerateCloudRunServiceURL(envConfig.ProjectID, envConfig.Region, envConfig.Service, envConfig.Revision, envConfig.Tab)
+	}
+	return GenerateTemplateURL(catalog["cloudrun"].Template, envConfig)
+}
+
+// buildLoggingURL opens the Logs Explorer with the environment's query applied.
+func buildLoggingURL(envConfig config.EnvironmentConfig) (string, error) {
+	return GenerateLogsExplorerURL(envConfig.ProjectID, envConfig.Query)
+}
+
+// buildGKEURL links to the most specific GKE page the configuration allows.
+func buildGKEURL(envConfig config.EnvironmentConfig) (string, error) {
+	return GenerateGKEURL(GKETargetFromConfig(envConfig))
+}
+
+// checkRequired reports the first required parameter of the entry that is
+// not set in the environment configuration.
+func (e CatalogEntry) checkRequired(envConfig config.EnvironmentConfig) error {
+	for _, param := range e.Required {
+		if ParamValue(envConfig, param) == "" {
+			return fmt.Errorf("service type '%s' requires '%s' to be set in the environment configuration", e.Name, param)
+		}
+	}
+	return nil
+}
+
+// ParamValue returns the value of the environment configuration field with
+// the given YAML key.
+func ParamValue(envConfig config.EnvironmentConfig, param string) string {
+	if field := paramField(&envConfig, param); field != nil {
+		return *field
+	}
+	return ""
+}
+
+// paramField returns a pointer to the environment configuration field with
+// the given YAML key, or nil if there is no such string field.
+func paramField(envConfig *config.EnvironmentConfig, param string) *string {
+	switch param {
+	case "project_id":
+		return &envConfig.ProjectID
+	case "region":
+		return &envConfig.Region
+	case "cluster":
+		return &envConfig.Cluster
+	case "location":
+		return &envConfig.Location
+	case "namespace":
+		return &envConfig.Namespace
+	case "workload":
+		return &envConfig.Workload
+	case "service":
+		return &envConfig.Service
+	case "instance":
+		return &envConfig.Instance
+	default:
+		return nil
+	}
+}
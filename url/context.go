@@ -0,0 +1,84 @@
+package url
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tom-gray/gcp-launch/config"
+)
+
+// contextValidators holds the accepted format for each configuration key that
+// a context argument may set.
+var contextValidators = map[string]*regexp.Regexp{
+	"region":    config.RegionPattern,
+	"location":  regexp.MustCompile(config.RegionPattern.String() + "|" + config.ZonePattern.String()),
+	"cluster":   regexp.MustCompile(`^[a-z]([-a-z0-9]{0,38}[a-z0-9])?$`),
+	"namespace": regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`),
+	"workload":  regexp.MustCompile(`^[a-z0-9]([-.a-z0-9]{0,251}[a-z0-9])?$`),
+	"service":   regexp.MustCompile(`^[a-z]([-a-z0-9]{0,47}[a-z0-9])?$`),
+	"instance":  regexp.MustCompile(`^[a-z]([-a-z0-9]{0,62}[a-z0-9])?$`),
+}
+
+// ContextParams returns the configuration keys the context argument may set
+// for a service type, in order of preference. A context_param on the service
+// type in the configuration takes precedence over the built-in catalog.
+func ContextParams(serviceType string, serviceConfig config.ServiceTypeConfig) []string {
+	if serviceConfig.ContextParam != "" {
+		return []string{serviceConfig.ContextParam}
+	}
+	if entry, ok := LookupCatalog(serviceType); ok {
+		return entry.ContextParams
+	}
+	return nil
+}
+
+// ApplyContextArg overrides the environment configuration with the context
+// argument given on the command line. An argument of the form key=value sets
+// that context parameter explicitly. Otherwise the argument is assigned to
+// the first parameter that already has it as a value in one of the service
+// type's environments, and failing that to the first parameter whose format
+// it matches, so for cloudrun "europe-west1" overrides the region while
+// "checkout-api" names a service. It returns the updated configuration and
+// the key that was set.
+func ApplyContextArg(serviceType string, serviceConfig config.ServiceTypeConfig, envConfig config.EnvironmentConfig, arg string) (config.EnvironmentConfig, string, error) {
+	params := ContextParams(serviceType, serviceConfig)
+	if len(params) == 0 {
+		return envConfig, "", fmt.Errorf("service type '%s' does not accept a context argument", serviceType)
+	}
+	fields := make(map[string]*string, len(params))
+	for _, param := range params {
+		field := paramField(&envConfig, param)
+		if _, ok := contextValidators[param]; field == nil || !ok {
+			return envConfig, "", fmt.Errorf("service type '%s' has unsupported context_param '%s'", serviceType, param)
+		}
+		fields[param] = field
+	}
+	if key, value, ok := strings.Cut(arg, "="); ok {
+		field, known := fields[key]
+		if !known {
+			return envConfig, "", fmt.Errorf("context argument '%s' sets '%s', but service type '%s' only accepts %s", arg, key, serviceType, strings.Join(params, " or "))
+		}
+		if !contextValidators[key].MatchString(value) {
+			return envConfig, "", fmt.Errorf("context argument '%s' is not a valid %s", value, key)
+		}
+		*field = value
+		return envConfig, key, nil
+	}
+	// Values already used in the configuration are unambiguous
+	for _, param := range params {
+		for _, env := range serviceConfig.Environments {
+			if ParamValue(env, param) == arg {
+				*fields[param] = arg
+				return envConfig, param, nil
+			}
+		}
+	}
+	for _, param := range params {
+		if contextValidators[param].MatchString(arg) {
+			*fields[param] = arg
+			return envConfig, param, nil
+		}
+	}
+	return envConfig, "", fmt.Errorf("context argument '%s' is not a valid %s for service type '%s'", arg, strings.Join(params, " or "), serviceType)
+}
@@ -0,0 +1,56 @@
+package url
+
+import (
+	"testing"
+
+	"github.com/tom-gray/gcp-launch/config"
+)
+
+func TestApplyContextArg(t *testing.T) {
+	base := config.EnvironmentConfig{ProjectID: "my-project", Region: "us-central1", Cluster: "prod-cluster"}
+	tests := []struct {
+		name          string
+		serviceType   string
+		serviceConf   config.ServiceTypeConfig
+		arg           string
+		expectedParam string
+		expectedValue string
+		expectError   bool
+	}{
+		{name: "cloudrun region", serviceType: "cloudrun", arg: "europe-west1", expectedParam: "region", expectedValue: "europe-west1"},
+		{name: "cloudrun service", serviceType: "cloudrun", arg: "checkout-api", expectedParam: "service", expectedValue: "checkout-api"},
+		{name: "gke zone", serviceType: "gke", arg: "us-central1-a", expectedParam: "location", expectedValue: "us-central1-a"},
+		{name: "gke cluster", serviceType: "gke", arg: "batch-cluster", expectedParam: "cluster", expectedValue: "batch-cluster"},
+		{name: "spanner instance", serviceType: "spanner", arg: "orders", expectedParam: "instance", expectedValue: "orders"},
+		{name: "configured context_param", serviceType: "custom", serviceConf: config.ServiceTypeConfig{ContextParam: "region"}, arg: "asia-east1", expectedParam: "region", expectedValue: "asia-east1"},
+		{name: "cloudrun service with version suffix", serviceType: "cloudrun", arg: "api-v2", expectedParam: "service", expectedValue: "api-v2"},
+		{name: "cloudrun service named like a version", serviceType: "cloudrun", arg: "orders-v1", expectedParam: "service", expectedValue: "orders-v1"},
+		{name: "gke cluster with number", serviceType: "gke", arg: "prod-cluster1", expectedParam: "cluster", expectedValue: "prod-cluster1"},
+		{name: "gke region", serviceType: "gke", arg: "northamerica-northeast1", expectedParam: "location", expectedValue: "northamerica-northeast1"},
+		{name: "known service value", serviceType: "cloudrun", serviceConf: config.ServiceTypeConfig{Environments: config.ServiceEnvironments{"prod": {Service: "us-east1"}}}, arg: "us-east1", expectedParam: "service", expectedValue: "us-east1"},
+		{name: "known cluster value", serviceType: "gke", serviceConf: config.ServiceTypeConfig{Environments: config.ServiceEnvironments{"prod": {Cluster: "europe-west1"}}}, arg: "europe-west1", expectedParam: "cluster", expectedValue: "europe-west1"},
+		{name: "explicit region", serviceType: "cloudrun", arg: "region=europe-west1", expectedParam: "region", expectedValue: "europe-west1"},
+		{name: "explicit service", serviceType: "cloudrun", arg: "service=us-east1", expectedParam: "service", expectedValue: "us-east1"},
+		{name: "explicit invalid region", serviceType: "cloudrun", arg: "region=api-v2", expectError: true},
+		{name: "explicit unknown key", serviceType: "cloudrun", arg: "cluster=main", expectError: true},
+		{name: "invalid value", serviceType: "spanner", arg: "Not_Valid", expectError: true},
+		{name: "no context parameter", serviceType: "logging", arg: "anything", expectError: true},
+		{name: "unknown context_param", serviceType: "custom", serviceConf: config.ServiceTypeConfig{ContextParam: "colour"}, arg: "red", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envConfig, param, err := ApplyContextArg(tt.serviceType, tt.serviceConf, base, tt.arg)
+			if (err != nil) != tt.expectError {
+				t.Errorf("ApplyContextArg() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if tt.expectError {
+				return
+			}
+			if param != tt.expectedParam || ParamValue(envConfig, param) != tt.expectedValue {
+				t.Errorf("ApplyContextArg() set %s = %q, want %s = %q", param, ParamValue(envConfig, param), tt.expectedParam, tt.expectedValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package url
+
+import (
+	"fmt"
+	neturl "net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tom-gray/gcp-launch/config"
+)
+
+// LogSeverities lists the Cloud Logging severities from lowest to highest.
+var LogSeverities = []string{"DEFAULT", "DEBUG", "INFO", "NOTICE", "WARNING", "ERROR", "CRITICAL", "ALERT", "EMERGENCY"}
+
+// BuildLogFilter assembles a Logging query language filter from the query,
+// one restriction per line (lines are implicitly ANDed).
+func BuildLogFilter(q config.LogQuery) (string, error) {
+	var lines []string
+	if q.ResourceType != "" {
+		lines = append(lines, fmt.Sprintf("resource.type=%s", strconv.Quote(q.ResourceType)))
+	}
+	if q.Severity != "" {
+		severity := strings.ToUpper(q.Severity)
+		if !slices.Contains(LogSeverities, severity) {
+			return "", fmt.Errorf("unknown log severity '%s' (expected one of: %s)", q.Severity, strings.Join(LogSeverities, ", "))
+		}
+		lines = append(lines, "severity>="+severity)
+	}
+	keys := make([]string, 0, len(q.Labels))
+	for k := range q.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("labels.%s=%s", strconv.Quote(k), strconv.Quote(q.Labels[k])))
+	}
+	if q.Text != "" {
+		lines = append(lines, strconv.Quote(q.Text))
+	}
+	if q.Filter != "" {
+		lines = append(lines, strings.TrimSpace(q.Filter))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// GenerateLogsExplorerURL constructs the Logs Explorer URL for a project with
+// the query and time window of q applied.
+// Format: https://console.cloud.google.com/logs/query;query=<filter>;duration=PT1H?project=<project_id>
+func GenerateLogsExplorerURL(projectID string, q config.LogQuery) (string, error) {
+	if projectID == "" {
+		return "", fmt.Errorf("cannot generate Logs Explorer URL: project_id is missing")
+	}
+	filter, err := BuildLogFilter(q)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString(consoleBaseURL + "/logs/query")
+	if filter != "" {
+		sb.WriteString(";query=" + escapeMatrixParam(filter))
+	}
+	window, err := logTimeWindow(q, time.Now())
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(window)
+	sb.WriteString("?project=" + neturl.QueryEscape(projectID))
+	return sb.String(), nil
+}
+
+// logTimeWindow returns the ;duration= or ;timeRange= path parameter for the
+// query's time window, or an empty string when none is set.
+func logTimeWindow(q config.LogQuery, now time.Time) (string, error) {
+	if q.Since != "" {
+		if q.From != "" || q.To != "" {
+			return "", fmt.Errorf("a log query cannot set both since and from/to")
+		}
+		d, err := ParseSince(q.Since)
+		if err != nil {
+			return "", err
+		}
+		return ";duration=" + isoDuration(d), nil
+	}
+	if q.From == "" {
+		if q.To != "" {
+			return "", fmt.Errorf("a log query with 'to' must also set 'from'")
+		}
+		return "", nil
+	}
+	from, err := time.Parse(time.RFC3339, q.From)
+	if err != nil {
+		return "", fmt.Errorf("invalid 'from' time '%s' (expected RFC 3339, e.g. 2024-05-01T09:00:00Z): %w", q.From, err)
+	}
+	to := now
+	if q.To != "" {
+		if to, err = time.Parse(time.RFC3339, q.To); err != nil {
+			return "", fmt.Errorf("invalid 'to' time '%s' (expected RFC 3339, e.g. 2024-05-01T10:00:00Z): %w", q.To, err)
+		}
+	}
+	if !to.After(from) {
+		return "", fmt.Errorf("log query 'to' (%s) must be after 'from' (%s)", q.To, q.From)
+	}
+	timeRange := from.UTC().Format(time.RFC3339) + "/" + to.UTC().Format(time.RFC3339)
+	return ";timeRange=" + escapeMatrixParam(timeRange), nil
+}
+
+// ParseSince parses a relative time window such as 30m, 1h or 7d. In
+// addition to time.ParseDuration units a whole number of days is accepted.
+func ParseSince(since string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(since, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(since)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid time window '%s' (expected e.g. 30m, 1h or 7d)", since)
+	}
+	return d, nil
+}
+
+// isoDuration formats a duration the way the Logs Explorer expects it,
+// e.g. PT1H or P7D.
+func isoDuration(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("P%dD", d/(24*time.Hour))
+	}
+	var sb strings.Builder
+	sb.WriteString("PT")
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&sb, "%dH", h)
+	}
+	if m := d % time.Hour / time.Minute; m > 0 {
+		fmt.Fprintf(&sb, "%dM", m)
+	}
+	if s := d % time.Minute / time.Second; s > 0 {
+		fmt.Fprintf(&sb, "%dS", s)
+	}
+	return sb.String()
+}
+
+// escapeMatrixParam percent-encodes a value for use in a ;key=value path
+// parameter. Spaces become %20 rather than +, which the console would keep.
+func escapeMatrixParam(value string) string {
+	return strings.ReplaceAll(neturl.QueryEscape(value), "+", "%20")
+}
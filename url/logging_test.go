@@ -0,0 +1,99 @@
+package url
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tom-gray/gcp-launch/config"
+)
+
+func TestGenerateLogsExplorerURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       config.LogQuery
+		expectedURL string
+		expectError bool
+	}{
+		{
+			name:        "no query",
+			expectedURL: "https://console.cloud.google.com/logs/query?project=my-project",
+		},
+		{
+			name:        "resource type and severity",
+			query:       config.LogQuery{ResourceType: "cloud_run_revision", Severity: "error"},
+			expectedURL: "https://console.cloud.google.com/logs/query;query=resource.type%3D%22cloud_run_revision%22%0Aseverity%3E%3DERROR?project=my-project",
+		},
+		{
+			name:        "labels, text and duration",
+			query:       config.LogQuery{Labels: map[string]string{"env": "prod"}, Text: "payment failed", Since: "1h"},
+			expectedURL: "https://console.cloud.google.com/logs/query;query=labels.%22env%22%3D%22prod%22%0A%22payment%20failed%22;duration=PT1H?project=my-project",
+		},
+		{
+			name:        "absolute time range",
+			query:       config.LogQuery{From: "2024-05-01T09:00:00Z", To: "2024-05-01T10:00:00Z"},
+			expectedURL: "https://console.cloud.google.com/logs/query;timeRange=2024-05-01T09%3A00%3A00Z%2F2024-05-01T10%3A00%3A00Z?project=my-project",
+		},
+		{
+			name:        "unknown severity",
+			query:       config.LogQuery{Severity: "LOUD"},
+			expectError: true,
+		},
+		{
+			name:        "since and from",
+			query:       config.LogQuery{Since: "1h", From: "2024-05-01T09:00:00Z"},
+			expectError: true,
+		},
+		{
+			name:        "to before from",
+			query:       config.LogQuery{From: "2024-05-01T10:00:00Z", To: "2024-05-01T09:00:00Z"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := GenerateLogsExplorerURL("my-project", tt.query)
+			if (err != nil) != tt.expectError {
+				t.Errorf("GenerateLogsExplorerURL() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if url != tt.expectedURL {
+				t.Errorf("GenerateLogsExplorerURL() got URL = %v, want %v", url, tt.expectedURL)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30m": 30 * time.Minute,
+		"1h":  time.Hour,
+		"7d":  7 * 24 * time.Hour,
+	}
+	for in, want := range tests {
+		got, err := ParseSince(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSince(%s) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "abc", "-1h", "xd"} {
+		if _, err := ParseSince(in); err == nil {
+			t.Errorf("ParseSince(%q) expected error, got nil", in)
+		}
+	}
+}
+
+func TestIsoDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:                    "PT1H",
+		90 * time.Minute:             "PT1H30M",
+		45 * time.Second:             "PT45S",
+		2 * 24 * time.Hour:           "P2D",
+		24*time.Hour + 5*time.Minute: "PT24H5M",
+	}
+	for in, want := range tests {
+		if got := isoDuration(in); got != want {
+			t.Errorf("isoDuration(%v) = %s; want %s", in, got, want)
+		}
+	}
+}
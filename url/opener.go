@@ -0,0 +1,181 @@
+package url
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// Opener opens a URL in a browser.
+type Opener interface {
+	Open(url string) error
+}
+
+// SystemOpener opens URLs with the platform's default handler
+// (xdg-open, open or start).
+type SystemOpener struct{}
+
+// Open implements Opener.
+func (SystemOpener) Open(url string) error {
+	return OpenURL(url)
+}
+
+// CommandOpener opens URLs by running a user-supplied command template such
+// as `google-chrome --profile-directory="Profile 2" {{.URL}}`. The template is
+// split into arguments with shell-like quoting before {{.URL}} is rendered, so
+// the URL is always passed as a single argument. If the template does not
+// reference {{.URL}}, the URL is appended as the last argument.
+type CommandOpener struct {
+	Template string
+}
+
+// Open implements Opener.
+func (o CommandOpener) Open(url string) error {
+	args, err := commandArgs(o.Template, url)
+	if err != nil {
+		return fmt.Errorf("failed to open URL '%s': %w", url, err)
+	}
+	return startCommand(url, args)
+}
+
+// BrowserEnvOpener opens URLs with the command(s) in $BROWSER. Following the
+// usual convention $BROWSER is a colon-separated list of commands tried in
+// order, in which %s is replaced by the URL.
+type BrowserEnvOpener struct{}
+
+// Open implements Opener.
+func (BrowserEnvOpener) Open(url string) error {
+	browsers := os.Getenv("BROWSER")
+	if browsers == "" {
+		return fmt.Errorf("failed to open URL '%s': $BROWSER is not set", url)
+	}
+	var errs []string
+	for _, browser := range strings.Split(browsers, ":") {
+		if strings.TrimSpace(browser) == "" {
+			continue
+		}
+		if strings.Contains(browser, "%s") {
+			browser = strings.ReplaceAll(browser, "%s", "{{.URL}}")
+		}
+		err := CommandOpener{Template: browser}.Open(url)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return fmt.Errorf("failed to open URL '%s' with $BROWSER: %s", url, strings.Join(errs, "; "))
+}
+
+// NewOpener returns the Opener for a browser setting from the configuration
+// or command line: "" or "default" for the system default, "$BROWSER" for the
+// BROWSER environment variable, and anything else as a command template.
+func NewOpener(browser string) Opener {
+	switch strings.TrimSpace(browser) {
+	case "", "default", "system":
+		return SystemOpener{}
+	case "$BROWSER", "env":
+		return BrowserEnvOpener{}
+	default:
+		return CommandOpener{Template: browser}
+	}
+}
+
+// commandArgs splits a command template into arguments and renders {{.URL}}
+// in each of them.
+func commandArgs(commandTemplate, url string) ([]string, error) {
+	words, err := splitWords(commandTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid browser command '%s': %w", commandTemplate, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("browser command is empty")
+	}
+	data := struct{ URL string }{URL: url}
+	usesURL := false
+	args := make([]string, 0, len(words)+1)
+	for _, word := range words {
+		if strings.Contains(word, "{{") {
+			tmpl, err := template.New("browser").Option("missingkey=error").Parse(word)
+			if err != nil {
+				return nil, fmt.Errorf("invalid browser command '%s': %w", commandTemplate, err)
+			}
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, data); err != nil {
+				return nil, fmt.Errorf("invalid browser command '%s': %w", commandTemplate, err)
+			}
+			word = sb.String()
+			usesURL = true
+		}
+		args = append(args, word)
+	}
+	if !usesURL {
+		args = append(args, url)
+	}
+	return args, nil
+}
+
+// splitWords splits s into words the way a POSIX shell would, honouring
+// single quotes, double quotes and backslash escapes.
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+// startCommand starts a browser command without waiting for it to exit, as a
+// browser that was not already running stays in the foreground.
+func startCommand(url string, args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open URL '%s' using command '%s': %w", url, strings.Join(args, " "), err)
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,47 @@
+package url
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandArgs(t *testing.T) {
+	const u = "https://console.cloud.google.com/run?project=p&region=r"
+	tests := []struct {
+		template string
+		expected []string
+	}{
+		{`google-chrome --profile-directory="Profile 2" {{.URL}}`, []string{"google-chrome", "--profile-directory=Profile 2", u}},
+		{`firefox -P 'work account' --new-tab`, []string{"firefox", "-P", "work account", "--new-tab", u}},
+		{`open -a Safari\ Technology\ Preview {{.URL}}`, []string{"open", "-a", "Safari Technology Preview", u}},
+		{`echo url={{.URL}}`, []string{"echo", "url=" + u}},
+	}
+	for _, tt := range tests {
+		got, err := commandArgs(tt.template, u)
+		if err != nil {
+			t.Errorf("commandArgs(%s) error = %v", tt.template, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("commandArgs(%s) = %q; want %q", tt.template, got, tt.expected)
+		}
+	}
+
+	for _, template := range []string{``, `chrome "unterminated`, `chrome {{.Nope}}`} {
+		if _, err := commandArgs(template, u); err == nil {
+			t.Errorf("commandArgs(%q) expected error, got nil", template)
+		}
+	}
+}
+
+func TestNewOpener(t *testing.T) {
+	if _, ok := NewOpener("").(SystemOpener); !ok {
+		t.Error(`NewOpener("") is not a SystemOpener`)
+	}
+	if _, ok := NewOpener("$BROWSER").(BrowserEnvOpener); !ok {
+		t.Error(`NewOpener("$BROWSER") is not a BrowserEnvOpener`)
+	}
+	if o, ok := NewOpener("firefox {{.URL}}").(CommandOpener); !ok || o.Template != "firefox {{.URL}}" {
+		t.Errorf(`NewOpener("firefox {{.URL}}") = %#v, want a CommandOpener`, o)
+	}
+}
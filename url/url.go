@@ -2,41 +2,96 @@ package url
 
 import (
 	"fmt"
+	neturl "net/url"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strings"
+	"text/template"
 
 	"github.com/tom-gray/gcp-launch/config"
 )
 
+const consoleBaseURL = "https://console.cloud.google.com"
+
 // GenerateServiceURL constructs the appropriate Google Cloud Console URL
 // based on the requested service type and environment configuration.
-func GenerateServiceURL(serviceType string, envConfig config.EnvironmentConfig) (string, error) {
+// The url_template of serviceConfig is used when set, otherwise the entry for
+// the service type in the built-in catalog.
+func GenerateServiceURL(serviceType string, serviceConfig config.ServiceTypeConfig, envConfig config.EnvironmentConfig) (string, error) {
 	if envConfig.ProjectID == "" {
 		return "", fmt.Errorf("cannot generate URL: project_id is missing for service type '%s'", serviceType)
 	}
-	const consoleBaseURL = "https://console.cloud.google.com"
 	var url string
-	switch serviceType {
-	case "logging":
-		url = fmt.Sprintf("%s/logs/viewer?project=%s", consoleBaseURL, envConfig.ProjectID)
-	case "cloudrun":
-		if envConfig.Region != "" {
-			url = GenerateCloudRunURL(envConfig.ProjectID, envConfig.Region)
-		} else {
-			url = fmt.Sprintf("%s/run?project=%s", consoleBaseURL, envConfig.ProjectID)
+	var err error
+	if serviceConfig.URLTemplate != "" {
+		url, err = GenerateTemplateURL(serviceConfig.URLTemplate, envConfig)
+	} else {
+		entry, ok := LookupCatalog(serviceType)
+		if !ok {
+			return "", fmt.Errorf("URL generation not supported for service type: '%s' (define a url_template for it in the configuration)", serviceType)
 		}
-	case "gke":
-		// Use the specific cluster details URL if cluster name is available
-		if envConfig.Cluster != "" {
-			url = GenerateGKEURL(envConfig.ProjectID, envConfig.Cluster) // Call the new function
-		} else {
-			// Fallback to the project-level cluster list
-			url = fmt.Sprintf("%s/kubernetes/list?project=%s", consoleBaseURL, envConfig.ProjectID)
+		if err := entry.checkRequired(envConfig); err != nil {
+			return "", err
 		}
-	case "spanner":
-		url = fmt.Sprintf("%s/spanner?project=%s", consoleBaseURL, envConfig.ProjectID)
-	default:
-		return "", fmt.Errorf("URL generation not supported for service type: '%s'", serviceType)
+		url, err = entry.generate(envConfig)
+	}
+	if err != nil {
+		return "", err
+	}
+	return WithAccount(url, envConfig.Account)
+}
+
+// WithAccount adds the authuser parameter selecting the Google account to a
+// console URL. The account is either an email address or the numeric index
+// of the account in the browser session. An authuser parameter already in
+// the URL is replaced. An empty account leaves the URL as is.
+func WithAccount(url string, account string) (string, error) {
+	if account == "" {
+		return url, nil
+	}
+	if err := config.ValidateAccount(account); err != nil {
+		return "", err
+	}
+	base, fragment, hasFragment := strings.Cut(url, "#")
+	if path, query, ok := strings.Cut(base, "?"); ok {
+		params := strings.Split(query, "&")
+		kept := params[:0]
+		for _, p := range params {
+			if !strings.HasPrefix(p, "authuser=") {
+				kept = append(kept, p)
+			}
+		}
+		base = path
+		if len(kept) > 0 {
+			base += "?" + strings.Join(kept, "&")
+		}
+	}
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	url = base + separator + "authuser=" + neturl.QueryEscape(account)
+	if hasFragment {
+		url += "#" + fragment
+	}
+	return url, nil
+}
+
+// GenerateTemplateURL renders a Go text/template URL against the environment
+// configuration, e.g. "https://console.cloud.google.com/bigquery?project={{.ProjectID}}".
+func GenerateTemplateURL(urlTemplate string, envConfig config.EnvironmentConfig) (string, error) {
+	tmpl, err := template.New("url").Option("missingkey=error").Parse(urlTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid url_template '%s': %w", urlTemplate, err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, envConfig); err != nil {
+		return "", fmt.Errorf("failed to render url_template '%s': %w", urlTemplate, err)
+	}
+	url := strings.TrimSpace(sb.String())
+	if url == "" {
+		return "", fmt.Errorf("url_template '%s' rendered an empty URL", urlTemplate)
 	}
 	return url, nil
 }
@@ -51,14 +106,155 @@ func GenerateCloudRunURL(projectID string, region string) string {
 	return url
 }
 
-// GenerateGKEURL constructs the Google Cloud Console URL for the GKE workload overview page.
-// It uses the format: https://console.cloud.google.com/kubernetes/workload/overview?inv=1&invt=Ab2VWw&project={project_id}
-func GenerateGKEURL(projectID string, cluster string) string {
-	// Format for workload overview page
-	const gkeURLFormat = "https://console.cloud.google.com/kubernetes/workload/overview?inv=1&invt=Ab2VWw&project=%s"
-	// Only use project ID for workload overview (cluster parameter is ignored for this URL format)
-	url := fmt.Sprintf(gkeURLFormat, projectID)
-	return url
+// CloudRunTabs lists the tabs of the Cloud Run service details page that can
+// be deep linked to. The first entry is the default.
+var CloudRunTabs = []string{"metrics", "logs", "revisions", "yaml", "triggers"}
+
+// GenerateCloudRunServiceURL constructs the Google Cloud Console URL for a
+// specific Cloud Run service, optionally on a given tab or revision.
+// Format: https://console.cloud.google.com/run/detail/<region>/<service>/<tab>?project=<project_id>
+func GenerateCloudRunServiceURL(projectID, region, service, revision, tab string) (string, error) {
+	if region == "" {
+		return "", fmt.Errorf("region is required to link to Cloud Run service '%s'", service)
+	}
+	if service == "" {
+		return "", fmt.Errorf("a Cloud Run service name is required")
+	}
+	if tab == "" {
+		tab = CloudRunTabs[0]
+		if revision != "" {
+			tab = "revisions"
+		}
+	}
+	tab = strings.ToLower(tab)
+	if !slices.Contains(CloudRunTabs, tab) {
+		return "", fmt.Errorf("unknown Cloud Run tab '%s' (expected one of: %s)", tab, strings.Join(CloudRunTabs, ", "))
+	}
+	if revision != "" && tab != "revisions" {
+		return "", fmt.Errorf("a revision can only be linked to on the 'revisions' tab, not '%s'", tab)
+	}
+	query := neturl.Values{}
+	query.Set("project", projectID)
+	if revision != "" {
+		query.Set("revision", revision)
+	}
+	url := fmt.Sprintf("%s/run/detail/%s/%s/%s?%s", consoleBaseURL,
+		neturl.PathEscape(region), neturl.PathEscape(service), tab, query.Encode())
+	return url, nil
+}
+
+// LocationType distinguishes zonal from regional GKE cluster locations.
+type LocationType string
+
+const (
+	LocationRegional LocationType = "regional"
+	LocationZonal    LocationType = "zonal"
+)
+
+// ParseLocation reports whether a GKE location is a region (us-central1) or
+// a zone (us-central1-a).
+func ParseLocation(location string) (LocationType, error) {
+	switch {
+	case config.RegionPattern.MatchString(location):
+		return LocationRegional, nil
+	case config.ZonePattern.MatchString(location):
+		return LocationZonal, nil
+	default:
+		return "", fmt.Errorf("'%s' is neither a GCP region (e.g. us-central1) nor a zone (e.g. us-central1-a)", location)
+	}
+}
+
+// GKEWorkloadKinds lists the workload kinds that can be deep linked to.
+// The first entry is the default.
+var GKEWorkloadKinds = []string{"deployment", "statefulset", "daemonset", "job", "cronjob", "pod"}
+
+// GKETarget identifies what a GKE console link should point at. Only
+// ProjectID is required; each further field narrows the link down.
+type GKETarget struct {
+	ProjectID string
+	// Location is the zone or region the cluster runs in.
+	Location  string
+	Cluster   string
+	Namespace string
+	// Kind and Name identify a single workload in Namespace.
+	Kind string
+	Name string
+}
+
+// GKETargetFromConfig builds a GKETarget from an environment configuration,
+// falling back to the region when no explicit location is set.
+func GKETargetFromConfig(envConfig config.EnvironmentConfig) GKETarget {
+	location := envConfig.Location
+	if location == "" {
+		location = envConfig.Region
+	}
+	return GKETarget{
+		ProjectID: envConfig.ProjectID,
+		Location:  location,
+		Cluster:   envConfig.Cluster,
+		Namespace: envConfig.Namespace,
+		Kind:      envConfig.WorkloadKind,
+		Name:      envConfig.Workload,
+	}
+}
+
+// GenerateGKEURL constructs the Google Cloud Console URL for a GKE target:
+//   - a workload:  /kubernetes/<kind>/<location>/<cluster>/<namespace>/<name>
+//   - a namespace: the workload overview filtered to the namespace (and cluster)
+//   - a cluster:   /kubernetes/clusters/details/<location>/<cluster>
+//   - otherwise the project's workload overview.
+//
+// Cluster and workload pages need the cluster's location. Without one a
+// workload is an error, and a cluster falls back to the workload overview,
+// filtered to the namespace if set.
+func GenerateGKEURL(target GKETarget) (string, error) {
+	if target.ProjectID == "" {
+		return "", fmt.Errorf("cannot generate GKE URL: project_id is missing")
+	}
+	if target.Location != "" {
+		if _, err := ParseLocation(target.Location); err != nil {
+			return "", fmt.Errorf("invalid GKE location: %w", err)
+		}
+	}
+	if target.Name != "" && target.Cluster == "" {
+		return "", fmt.Errorf("a cluster is required to link to GKE workload '%s'", target.Name)
+	}
+	if target.Name != "" && target.Location == "" {
+		return "", fmt.Errorf("a location (or region) of cluster '%s' is required to link to GKE workload '%s'", target.Cluster, target.Name)
+	}
+	query := neturl.Values{}
+	query.Set("project", target.ProjectID)
+
+	switch {
+	case target.Name != "":
+		kind := strings.ToLower(target.Kind)
+		if kind == "" {
+			kind = GKEWorkloadKinds[0]
+		}
+		if !slices.Contains(GKEWorkloadKinds, kind) {
+			return "", fmt.Errorf("unknown GKE workload kind '%s' (expected one of: %s)", target.Kind, strings.Join(GKEWorkloadKinds, ", "))
+		}
+		namespace := target.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		return fmt.Sprintf("%s/kubernetes/%s/%s/%s/%s/%s?%s", consoleBaseURL, kind,
+			neturl.PathEscape(target.Location), neturl.PathEscape(target.Cluster),
+			neturl.PathEscape(namespace), neturl.PathEscape(target.Name), query.Encode()), nil
+	case target.Namespace != "":
+		// The workload overview keeps its filters in the pageState parameter.
+		filters := fmt.Sprintf(`"n":["%s"]`, target.Namespace)
+		if target.Cluster != "" && target.Location != "" {
+			filters = fmt.Sprintf(`"c":["gke/%s/%s"],%s`, target.Location, target.Cluster, filters)
+		}
+		query.Set("pageState", fmt.Sprintf(`("savedViews":(%s))`, filters))
+		return fmt.Sprintf("%s/kubernetes/workload/overview?%s", consoleBaseURL, query.Encode()), nil
+	case target.Cluster != "" && target.Location != "":
+		return fmt.Sprintf("%s/kubernetes/clusters/details/%s/%s?%s", consoleBaseURL,
+			neturl.PathEscape(target.Location), neturl.PathEscape(target.Cluster), query.Encode()), nil
+	default:
+		return fmt.Sprintf("%s/kubernetes/workload/overview?%s", consoleBaseURL, query.Encode()), nil
+	}
 }
 
 // OpenURL attempts to open the specified URL in the default web browser.
@@ -1,6 +1,7 @@
 package url
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/tom-gray/gcp-launch/config"
@@ -10,6 +11,7 @@ func TestGenerateServiceURL(t *testing.T) {
 	tests := []struct {
 		name        string
 		serviceType string
+		serviceConf config.ServiceTypeConfig
 		envConfig   config.EnvironmentConfig
 		expectedURL string
 		expectError bool
@@ -18,7 +20,7 @@ func TestGenerateServiceURL(t *testing.T) {
 			name:        "logging service",
 			serviceType: "logging",
 			envConfig:   config.EnvironmentConfig{ProjectID: "test-project"},
-			expectedURL: "https://console.cloud.google.com/logs/viewer?project=test-project",
+			expectedURL: "https://console.cloud.google.com/logs/query?project=test-project",
 			expectError: false,
 		},
 		{
@@ -28,11 +30,25 @@ func TestGenerateServiceURL(t *testing.T) {
 			expectedURL: "https://console.cloud.google.com/run?project=test-project&region=us-central1",
 			expectError: false,
 		},
+		{
+			name:        "cloudrun service deep link",
+			serviceType: "cloudrun",
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project", Region: "us-central1", Service: "checkout-api", Tab: "yaml"},
+			expectedURL: "https://console.cloud.google.com/run/detail/us-central1/checkout-api/yaml?project=test-project",
+			expectError: false,
+		},
 		{
 			name:        "gke service with cluster",
 			serviceType: "gke",
 			envConfig:   config.EnvironmentConfig{ProjectID: "test-project", Cluster: "test-cluster"},
-			expectedURL: "https://console.cloud.google.com/kubernetes/workload/overview?inv=1&invt=Ab2VWw&project=test-project",
+			expectedURL: "https://console.cloud.google.com/kubernetes/workload/overview?project=test-project",
+			expectError: false,
+		},
+		{
+			name:        "gke service with cluster and region",
+			serviceType: "gke",
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project", Region: "us-central1", Cluster: "test-cluster"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/clusters/details/us-central1/test-cluster?project=test-project",
 			expectError: false,
 		},
 		{
@@ -42,6 +58,58 @@ func TestGenerateServiceURL(t *testing.T) {
 			expectedURL: "https://console.cloud.google.com/spanner?project=test-project",
 			expectError: false,
 		},
+		{
+			name:        "catalog service",
+			serviceType: "bigquery",
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project"},
+			expectedURL: "https://console.cloud.google.com/bigquery?project=test-project",
+			expectError: false,
+		},
+		{
+			name:        "configured url_template",
+			serviceType: "bigquery",
+			serviceConf: config.ServiceTypeConfig{URLTemplate: "https://console.cloud.google.com/bigquery?project={{.ProjectID}}"},
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project"},
+			expectedURL: "https://console.cloud.google.com/bigquery?project=test-project",
+			expectError: false,
+		},
+		{
+			name:        "url_template overrides built-in",
+			serviceType: "cloudrun",
+			serviceConf: config.ServiceTypeConfig{URLTemplate: "https://console.cloud.google.com/run/overview?project={{.ProjectID}}&r={{.Region}}"},
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project", Region: "us-central1"},
+			expectedURL: "https://console.cloud.google.com/run/overview?project=test-project&r=us-central1",
+			expectError: false,
+		},
+		{
+			name:        "invalid url_template",
+			serviceType: "bigquery",
+			serviceConf: config.ServiceTypeConfig{URLTemplate: "https://console.cloud.google.com/{{.Unknown}}"},
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project"},
+			expectedURL: "",
+			expectError: true,
+		},
+		{
+			name:        "account email",
+			serviceType: "bigquery",
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project", Account: "me@example.com"},
+			expectedURL: "https://console.cloud.google.com/bigquery?project=test-project&authuser=me%40example.com",
+			expectError: false,
+		},
+		{
+			name:        "account index on logs explorer",
+			serviceType: "logging",
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project", Account: "1", Query: config.LogQuery{Since: "1h"}},
+			expectedURL: "https://console.cloud.google.com/logs/query;duration=PT1H?project=test-project&authuser=1",
+			expectError: false,
+		},
+		{
+			name:        "invalid account",
+			serviceType: "bigquery",
+			envConfig:   config.EnvironmentConfig{ProjectID: "test-project", Account: "not-an-account"},
+			expectedURL: "",
+			expectError: true,
+		},
 		{
 			name:        "unsupported service type",
 			serviceType: "unsupported",
@@ -60,7 +128,7 @@ func TestGenerateServiceURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			url, err := GenerateServiceURL(tt.serviceType, tt.envConfig)
+			url, err := GenerateServiceURL(tt.serviceType, tt.serviceConf, tt.envConfig)
 			if (err != nil) != tt.expectError {
 				t.Errorf("GenerateServiceURL() error = %v, expectError %v", err, tt.expectError)
 				return
@@ -83,11 +151,257 @@ func TestGenerateCloudRunURL(t *testing.T) {
 }
 
 func TestGenerateGKEURL(t *testing.T) {
-	projectID := "my-project"
-	cluster := "my-cluster"
-	expected := "https://console.cloud.google.com/kubernetes/workload/overview?inv=1&invt=Ab2VWw&project=my-project"
-	actual := GenerateGKEURL(projectID, cluster)
-	if actual != expected {
-		t.Errorf("GenerateGKEURL(%s, %s) = %s; want %s", projectID, cluster, actual, expected)
+	tests := []struct {
+		name        string
+		target      GKETarget
+		expectedURL string
+		expectError bool
+	}{
+		{
+			name:        "project overview",
+			target:      GKETarget{ProjectID: "my-project"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/workload/overview?project=my-project",
+		},
+		{
+			name:        "regional cluster",
+			target:      GKETarget{ProjectID: "my-project", Location: "europe-west1", Cluster: "my-cluster"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/clusters/details/europe-west1/my-cluster?project=my-project",
+		},
+		{
+			name:        "zonal cluster",
+			target:      GKETarget{ProjectID: "my-project", Location: "europe-west1-b", Cluster: "my-cluster"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/clusters/details/europe-west1-b/my-cluster?project=my-project",
+		},
+		{
+			name:        "namespace",
+			target:      GKETarget{ProjectID: "my-project", Location: "europe-west1", Cluster: "my-cluster", Namespace: "payments"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/workload/overview?pageState=%28%22savedViews%22%3A%28%22c%22%3A%5B%22gke%2Feurope-west1%2Fmy-cluster%22%5D%2C%22n%22%3A%5B%22payments%22%5D%29%29&project=my-project",
+		},
+		{
+			name:        "deployment",
+			target:      GKETarget{ProjectID: "my-project", Location: "europe-west1", Cluster: "my-cluster", Namespace: "payments", Name: "checkout"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/deployment/europe-west1/my-cluster/payments/checkout?project=my-project",
+		},
+		{
+			name:        "statefulset in default namespace",
+			target:      GKETarget{ProjectID: "my-project", Location: "europe-west1-b", Cluster: "my-cluster", Kind: "StatefulSet", Name: "redis"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/statefulset/europe-west1-b/my-cluster/default/redis?project=my-project",
+		},
+		{
+			name:        "cluster without location",
+			target:      GKETarget{ProjectID: "my-project", Cluster: "my-cluster"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/workload/overview?project=my-project",
+		},
+		{
+			name:        "workload without location",
+			target:      GKETarget{ProjectID: "my-project", Cluster: "my-cluster", Namespace: "payments", Name: "checkout"},
+			expectError: true,
+		},
+		{
+			name:        "namespace without location",
+			target:      GKETarget{ProjectID: "my-project", Cluster: "my-cluster", Namespace: "payments"},
+			expectedURL: "https://console.cloud.google.com/kubernetes/workload/overview?pageState=%28%22savedViews%22%3A%28%22n%22%3A%5B%22payments%22%5D%29%29&project=my-project",
+		},
+		{
+			name:        "invalid location",
+			target:      GKETarget{ProjectID: "my-project", Location: "moon-base", Cluster: "my-cluster"},
+			expectError: true,
+		},
+		{
+			name:        "unknown workload kind",
+			target:      GKETarget{ProjectID: "my-project", Location: "europe-west1", Cluster: "my-cluster", Kind: "replicaset", Name: "x"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := GenerateGKEURL(tt.target)
+			if (err != nil) != tt.expectError {
+				t.Errorf("GenerateGKEURL() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if url != tt.expectedURL {
+				t.Errorf("GenerateGKEURL() got URL = %v, want %v", url, tt.expectedURL)
+			}
+		})
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	if lt, err := ParseLocation("us-central1"); err != nil || lt != LocationRegional {
+		t.Errorf("ParseLocation(us-central1) = %v, %v; want regional", lt, err)
+	}
+	if lt, err := ParseLocation("northamerica-northeast1-a"); err != nil || lt != LocationZonal {
+		t.Errorf("ParseLocation(northamerica-northeast1-a) = %v, %v; want zonal", lt, err)
+	}
+	if _, err := ParseLocation("us-central"); err == nil {
+		t.Error("ParseLocation(us-central) expected error, got nil")
+	}
+}
+
+func TestWithAccount(t *testing.T) {
+	tests := []struct {
+		url      string
+		account  string
+		expected string
+	}{
+		{"https://console.cloud.google.com/run?project=p", "", "https://console.cloud.google.com/run?project=p"},
+		{"https://console.cloud.google.com/run?project=p", "2", "https://console.cloud.google.com/run?project=p&authuser=2"},
+		{"https://example.com/dashboard", "ops@example.com", "https://example.com/dashboard?authuser=ops%40example.com"},
+		{"https://example.com/page?a=b#section", "0", "https://example.com/page?a=b&authuser=0#section"},
+		{"https://console.cloud.google.com/run?project=p&authuser=0", "ro@example.com", "https://console.cloud.google.com/run?project=p&authuser=ro%40example.com"},
+		{"https://console.cloud.google.com/run?authuser=0&project=p#x", "1", "https://console.cloud.google.com/run?project=p&authuser=1#x"},
+		{"https://example.com/?authuser=0", "1", "https://example.com/?authuser=1"},
+	}
+	for _, tt := range tests {
+		got, err := WithAccount(tt.url, tt.account)
+		if err != nil || got != tt.expected {
+			t.Errorf("WithAccount(%s, %s) = %s, %v; want %s", tt.url, tt.account, got, err, tt.expected)
+		}
+	}
+	for _, account := range []string{"-1", "nobody", "@example.com", "me@localhost"} {
+		if _, err := WithAccount("https://example.com", account); err == nil {
+			t.Errorf("WithAccount(%s) expected error, got nil", account)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	entries := Catalog()
+	if len(entries) < 50 {
+		t.Errorf("Catalog() has %d entries; want at least 50", len(entries))
+	}
+	values := map[string]string{"project_id": "test-project", "region": "us-central1", "instance": "test-instance", "cluster": "test-cluster", "location": "us-central1"}
+	for _, e := range entries {
+		// Only the required keys are set, so every value the URL needs
+		// must be declared
+		var envConfig config.EnvironmentConfig
+		for _, param := range e.Required {
+			field := paramField(&envConfig, param)
+			if field == nil || values[param] == "" {
+				t.Fatalf("%s requires unknown key '%s'", e.Name, param)
+			}
+			*field = values[param]
+		}
+		url, err := GenerateServiceURL(e.Name, config.ServiceTypeConfig{}, envConfig)
+		if err != nil {
+			t.Errorf("GenerateServiceURL(%s) error = %v", e.Name, err)
+			continue
+		}
+		if !strings.HasPrefix(url, "https://console.cloud.google.com/") || !strings.Contains(url, "project=test-project") {
+			t.Errorf("GenerateServiceURL(%s) = %s; want a console URL for test-project", e.Name, url)
+		}
+		if strings.Contains(url, "=&") || strings.HasSuffix(url, "=") || strings.Contains(strings.TrimPrefix(url, "https://"), "//") {
+			t.Errorf("GenerateServiceURL(%s) = %s; has an empty value, so a key it needs is not required", e.Name, url)
+		}
+		if missing := e.checkRequired(config.EnvironmentConfig{}); missing == nil {
+			t.Errorf("%s accepts an empty configuration; want project_id required", e.Name)
+		}
+	}
+	if _, ok := LookupCatalog("pubsub-topics"); ok {
+		t.Errorf("LookupCatalog(pubsub-topics) found the duplicate of pubsub")
+	}
+}
+
+func TestCatalogURLs(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceType string
+		envConfig   config.EnvironmentConfig
+		expectedURL string
+		expectError string
+	}{
+		{name: "cloudrun", serviceType: "cloudrun", envConfig: config.EnvironmentConfig{ProjectID: "p1", Region: "europe-west1"}, expectedURL: "https://console.cloud.google.com/run?project=p1&region=europe-west1"},
+		{name: "cloudrun without region", serviceType: "cloudrun", envConfig: config.EnvironmentConfig{ProjectID: "p1"}, expectError: "service type 'cloudrun' requires 'region'"},
+		{name: "spanner", serviceType: "spanner", envConfig: config.EnvironmentConfig{ProjectID: "p1"}, expectedURL: "https://console.cloud.google.com/spanner?project=p1"},
+		{name: "spanner instance", serviceType: "spanner", envConfig: config.EnvironmentConfig{ProjectID: "p1", Instance: "orders"}, expectedURL: "https://console.cloud.google.com/spanner/instances/orders/details/databases?project=p1"},
+		{name: "cloudsql", serviceType: "cloudsql", envConfig: config.EnvironmentConfig{ProjectID: "p1"}, expectedURL: "https://console.cloud.google.com/sql/instances?project=p1"},
+		{name: "cloudsql instance", serviceType: "cloudsql", envConfig: config.EnvironmentConfig{ProjectID: "p1", Instance: "main-db"}, expectedURL: "https://console.cloud.google.com/sql/instances/main-db/overview?project=p1"},
+		{name: "bigtable instance", serviceType: "bigtable", envConfig: config.EnvironmentConfig{ProjectID: "p1", Instance: "events"}, expectedURL: "https://console.cloud.google.com/bigtable/instances/events/overview?project=p1"},
+		{name: "pubsub", serviceType: "pubsub", envConfig: config.EnvironmentConfig{ProjectID: "p1"}, expectedURL: "https://console.cloud.google.com/cloudpubsub/topic/list?project=p1"},
+		{name: "pubsub subscriptions", serviceType: "pubsub-subscriptions", envConfig: config.EnvironmentConfig{ProjectID: "p1"}, expectedURL: "https://console.cloud.google.com/cloudpubsub/subscription/list?project=p1"},
+		{name: "billing", serviceType: "billing", envConfig: config.EnvironmentConfig{ProjectID: "p1"}, expectedURL: "https://console.cloud.google.com/billing/linkedaccount?project=p1"},
+		{name: "without project", serviceType: "bigquery", envConfig: config.EnvironmentConfig{Region: "us-central1"}, expectError: "project_id is missing for service type 'bigquery'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := GenerateServiceURL(tt.serviceType, config.ServiceTypeConfig{}, tt.envConfig)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("GenerateServiceURL() error = %v, want it to contain %q", err, tt.expectError)
+				}
+				return
+			}
+			if err != nil || url != tt.expectedURL {
+				t.Errorf("GenerateServiceURL() = %s, %v; want %s", url, err, tt.expectedURL)
+			}
+		})
+	}
+}
+
+func TestGenerateCloudRunServiceURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		region      string
+		service     string
+		revision    string
+		tab         string
+		expectedURL string
+		expectError bool
+	}{
+		{
+			name:        "default tab",
+			region:      "us-central1",
+			service:     "checkout-api",
+			expectedURL: "https://console.cloud.google.com/run/detail/us-central1/checkout-api/metrics?project=my-project",
+		},
+		{
+			name:        "logs tab",
+			region:      "us-central1",
+			service:     "checkout-api",
+			tab:         "logs",
+			expectedURL: "https://console.cloud.google.com/run/detail/us-central1/checkout-api/logs?project=my-project",
+		},
+		{
+			name:        "revision",
+			region:      "us-central1",
+			service:     "checkout-api",
+			revision:    "checkout-api-00042-abc",
+			expectedURL: "https://console.cloud.google.com/run/detail/us-central1/checkout-api/revisions?project=my-project&revision=checkout-api-00042-abc",
+		},
+		{
+			name:        "revision on another tab",
+			region:      "us-central1",
+			service:     "checkout-api",
+			revision:    "checkout-api-00042-abc",
+			tab:         "logs",
+			expectError: true,
+		},
+		{
+			name:        "unknown tab",
+			region:      "us-central1",
+			service:     "checkout-api",
+			tab:         "settings",
+			expectError: true,
+		},
+		{
+			name:        "missing region",
+			service:     "checkout-api",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := GenerateCloudRunServiceURL("my-project", tt.region, tt.service, tt.revision, tt.tab)
+			if (err != nil) != tt.expectError {
+				t.Errorf("GenerateCloudRunServiceURL() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if url != tt.expectedURL {
+				t.Errorf("GenerateCloudRunServiceURL() got URL = %v, want %v", url, tt.expectedURL)
+			}
+		})
 	}
 }